@@ -418,6 +418,25 @@ func (ks *KeyStore) NewAccount(passphrase string) (accounts.Account, error) {
 	return account, nil
 }
 
+// NewAccountWithScrypt generates a new key and stores it into the key directory,
+// encrypting it with the passphrase using the given scrypt parameters instead of
+// the ones the keystore was configured with.
+func (ks *KeyStore) NewAccountWithScrypt(passphrase string, scryptN, scryptP int) (accounts.Account, error) {
+	storage := ks.storage
+	if store, ok := ks.storage.(*keyStorePassphrase); ok {
+		custom := *store
+		custom.scryptN, custom.scryptP = scryptN, scryptP
+		storage = &custom
+	}
+	_, account, err := storeNewKey(storage, crand.Reader, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	ks.cache.add(account)
+	ks.refreshWallets()
+	return account, nil
+}
+
 // Export exports as a JSON key, encrypted with newPassphrase.
 func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string) (keyJSON []byte, err error) {
 	_, key, err := ks.getDecryptedKey(a, passphrase)
@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"encoding/json"
 	"math/rand"
 	"os"
 	"runtime"
@@ -69,6 +70,31 @@ func TestKeyStore(t *testing.T) {
 	}
 }
 
+func TestNewAccountWithScrypt(t *testing.T) {
+	_, ks := tmpKeyStore(t, true)
+	a, err := ks.NewAccountWithScrypt("foo", 2*veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ks.HasAddress(a.Address) {
+		t.Errorf("HasAccount(%x) should've returned true", a.Address)
+	}
+	keyjson, err := os.ReadFile(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key encryptedKeyJSONV3
+	if err := json.Unmarshal(keyjson, &key); err != nil {
+		t.Fatal(err)
+	}
+	if n := key.Crypto.KDFParams["n"]; n != float64(2*veryLightScryptN) {
+		t.Errorf("wrong scrypt N in key file: have %v, want %d", n, 2*veryLightScryptN)
+	}
+	if err := ks.Unlock(a, "foo"); err != nil {
+		t.Errorf("failed to unlock account: %v", err)
+	}
+}
+
 func TestSign(t *testing.T) {
 	_, ks := tmpKeyStore(t, true)
 
@@ -186,3 +186,32 @@ func BenchmarkDifficultyCalculator(b *testing.B) {
 		}
 	})
 }
+
+// Tests that the seal hash is pinned to the legacy encoding of the header fields
+// preceding the seal, with the base fee appended post-London.
+func TestSealHash(t *testing.T) {
+	header := &types.Header{
+		ParentHash:  common.HexToHash("0x01"),
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    common.HexToAddress("0x02"),
+		Root:        common.HexToHash("0x03"),
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  big.NewInt(131072),
+		Number:      big.NewInt(1),
+		GasLimit:    8000000,
+		GasUsed:     21000,
+		Time:        1500000000,
+		Extra:       []byte("ethash"),
+		MixDigest:   common.HexToHash("0x04"),
+		Nonce:       types.EncodeNonce(5),
+	}
+	ethash := NewFaker()
+	if have, want := ethash.SealHash(header), common.HexToHash("0x7ebb619b8e83fa0c36956be6c27cb77032d4905190cddca4cd83dfea66282503"); have != want {
+		t.Errorf("seal hash mismatch: have %x, want %x", have, want)
+	}
+	header.BaseFee = big.NewInt(1000000000)
+	if have, want := ethash.SealHash(header), common.HexToHash("0xd2758ef4ada602e0cbcfdae4c5974acddcbdfeaf1ac0cd6988d1daac5354d796"); have != want {
+		t.Errorf("london seal hash mismatch: have %x, want %x", have, want)
+	}
+}
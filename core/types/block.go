@@ -18,16 +18,23 @@
 package types
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math"
 	"math/big"
 	"reflect"
+	"sort"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	cmath "github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -36,6 +43,14 @@ var (
 	EmptyUncleHash = rlpHash([]*Header(nil))
 )
 
+// HeaderExtraMax is the maximum size of the extra-data field accepted in a header.
+// It is way beyond what any sane production value should hold.
+const HeaderExtraMax = 100 * 1024
+
+// ErrHeaderExtraTooLarge is returned when decoding a header whose extra-data is
+// larger than HeaderExtraMax.
+var ErrHeaderExtraTooLarge = errors.New("header extra-data too large")
+
 // A BlockNonce is a 64-bit hash which proves (combined with the
 // mix-hash) that a sufficient amount of computation has been carried
 // out on a block.
@@ -112,6 +127,157 @@ func (h *Header) Hash() common.Hash {
 	return rlpHash(h)
 }
 
+// HashNoNonce returns the hash of the header excluding the MixDigest and Nonce
+// seal fields, which is the digest proof-of-work is computed over.
+func (h *Header) HashNoNonce() common.Hash {
+	enc := []interface{}{
+		h.ParentHash,
+		h.UncleHash,
+		h.Coinbase,
+		h.Root,
+		h.TxHash,
+		h.ReceiptHash,
+		h.Bloom,
+		h.Difficulty,
+		h.Number,
+		h.GasLimit,
+		h.GasUsed,
+		h.Time,
+		h.Extra,
+	}
+	if h.BaseFee != nil {
+		enc = append(enc, h.BaseFee)
+	}
+	return rlpHash(enc)
+}
+
+// PowInput returns the two inputs of proof-of-work verification: the hash of the
+// header without its seal and the decoded nonce.
+func (h *Header) PowInput() (hash common.Hash, nonce uint64) {
+	return h.HashNoNonce(), h.Nonce.Uint64()
+}
+
+// VerifySeal extracts the proof-of-work inputs of the header and hands them to
+// the given verifier, keeping the actual seal verification outside of this package.
+func (h *Header) VerifySeal(verify func(hashNoNonce common.Hash, nonce uint64, mix common.Hash, difficulty *big.Int) error) error {
+	hash, nonce := h.PowInput()
+	return verify(hash, nonce, h.MixDigest, h.Difficulty)
+}
+
+// Epoch returns the proof-of-work epoch of the header for the given epoch length,
+// e.g. 30000 blocks for ethash. Zero is returned for a header without number or
+// for a zero epoch length.
+func (h *Header) Epoch(epochLength uint64) uint64 {
+	if h.Number == nil || epochLength == 0 {
+		return 0
+	}
+	return h.Number.Uint64() / epochLength
+}
+
+// PreferSeal picks between two seals of the same header payload, returning the one
+// with the numerically lower hash. Nil is returned if the two headers do not share
+// the same HashNoNonce, i.e. they are not competing seals of the same payload.
+func PreferSeal(a, b *Header) *Header {
+	if a.HashNoNonce() != b.HashNoNonce() {
+		return nil
+	}
+	if ah, bh := a.Hash(), b.Hash(); bytes.Compare(bh[:], ah[:]) < 0 {
+		return b
+	}
+	return a
+}
+
+// DecodeRLP implements rlp.Decoder, rejecting headers whose extra-data exceeds
+// HeaderExtraMax.
+func (h *Header) DecodeRLP(s *rlp.Stream) error {
+	type rlpHeader Header // avoid recursing into DecodeRLP
+	if err := s.Decode((*rlpHeader)(h)); err != nil {
+		return err
+	}
+	if eLen := len(h.Extra); eLen > HeaderExtraMax {
+		return fmt.Errorf("%w: size %d", ErrHeaderExtraTooLarge, eLen)
+	}
+	return nil
+}
+
+// HashInto computes the same hash as Hash, but RLP encodes the header directly
+// into the given keccak256 hasher, allowing callers to reuse their hasher state.
+// The hasher is reset before use.
+func (h *Header) HashInto(hasher hash.Hash) (sum common.Hash) {
+	hasher.Reset()
+	rlp.Encode(hasher, h)
+	hasher.Sum(sum[:0])
+	return sum
+}
+
+// DecodeHeaderExact decodes a header from the stream, requiring the header list to
+// contain exactly the 15 legacy header fields, optionally followed by the base fee,
+// and nothing else.
+func DecodeHeaderExact(s *rlp.Stream) (*Header, error) {
+	if _, err := s.List(); err != nil {
+		return nil, err
+	}
+	h := new(Header)
+	fields := []struct {
+		name string
+		ptr  interface{}
+	}{
+		{"ParentHash", &h.ParentHash},
+		{"UncleHash", &h.UncleHash},
+		{"Coinbase", &h.Coinbase},
+		{"Root", &h.Root},
+		{"TxHash", &h.TxHash},
+		{"ReceiptHash", &h.ReceiptHash},
+		{"Bloom", &h.Bloom},
+		{"Difficulty", &h.Difficulty},
+		{"Number", &h.Number},
+		{"GasLimit", &h.GasLimit},
+		{"GasUsed", &h.GasUsed},
+		{"Time", &h.Time},
+		{"Extra", &h.Extra},
+		{"MixDigest", &h.MixDigest},
+		{"Nonce", &h.Nonce},
+	}
+	for _, field := range fields {
+		if err := s.Decode(field.ptr); err == rlp.EOL {
+			return nil, fmt.Errorf("missing header field %s", field.name)
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid header field %s: %v", field.name, err)
+		}
+	}
+	if s.MoreDataInList() {
+		if err := s.Decode(&h.BaseFee); err != nil {
+			return nil, fmt.Errorf("invalid header field BaseFee: %v", err)
+		}
+	}
+	if s.MoreDataInList() {
+		return nil, errors.New("too many header fields")
+	}
+	if err := s.ListEnd(); err != nil {
+		return nil, err
+	}
+	if eLen := len(h.Extra); eLen > HeaderExtraMax {
+		return nil, fmt.Errorf("%w: size %d", ErrHeaderExtraTooLarge, eLen)
+	}
+	return h, nil
+}
+
+// StorageRLP returns the RLP encoding of the header used for database storage,
+// which is the same nonce-inclusive encoding the header hash is computed over.
+func (h *Header) StorageRLP() ([]byte, error) {
+	return rlp.EncodeToBytes(h)
+}
+
+// HeaderFromStorageRLP decodes a header from its storage encoding, as produced
+// by Header.StorageRLP.
+func HeaderFromStorageRLP(data []byte) (*Header, error) {
+	h := new(Header)
+	if err := rlp.DecodeBytes(data, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
 var headerSize = common.StorageSize(reflect.TypeOf(Header{}).Size())
 
 // Size returns the approximate memory used by all internal contents. It is used
@@ -125,15 +291,19 @@ func (h *Header) Size() common.StorageSize {
 // that the unbounded fields are stuffed with junk data to add processing
 // overhead
 func (h *Header) SanityCheck() error {
-	if h.Number != nil && !h.Number.IsUint64() {
+	if h.Number == nil {
+		return errors.New("missing block number")
+	}
+	if h.Difficulty == nil {
+		return errors.New("missing block difficulty")
+	}
+	if !h.Number.IsUint64() {
 		return fmt.Errorf("too large block number: bitlen %d", h.Number.BitLen())
 	}
-	if h.Difficulty != nil {
-		if diffLen := h.Difficulty.BitLen(); diffLen > 80 {
-			return fmt.Errorf("too large block difficulty: bitlen %d", diffLen)
-		}
+	if diffLen := h.Difficulty.BitLen(); diffLen > 80 {
+		return fmt.Errorf("too large block difficulty: bitlen %d", diffLen)
 	}
-	if eLen := len(h.Extra); eLen > 100*1024 {
+	if eLen := len(h.Extra); eLen > HeaderExtraMax {
 		return fmt.Errorf("too large block extradata: size %d", eLen)
 	}
 	if h.BaseFee != nil {
@@ -155,6 +325,121 @@ func (h *Header) EmptyReceipts() bool {
 	return h.ReceiptHash == EmptyRootHash
 }
 
+// HasExtra returns true if the header carries any extra data.
+func (h *Header) HasExtra() bool {
+	return len(h.Extra) > 0
+}
+
+// ExtraHasPrefix returns true if the extra data of the header starts with the
+// given prefix.
+func (h *Header) ExtraHasPrefix(prefix []byte) bool {
+	return bytes.HasPrefix(h.Extra, prefix)
+}
+
+// Density returns the fraction of the gas limit used by the block, a cheap proxy
+// for the amount of state changes it carries. Zero is returned for a nil header
+// or one without gas limit.
+func (h *Header) Density() float64 {
+	if h == nil || h.GasLimit == 0 {
+		return 0
+	}
+	return float64(h.GasUsed) / float64(h.GasLimit)
+}
+
+// DifficultyRatio returns the difficulty of the header relative to that of its
+// parent, e.g. 1.1 for a 10% increase. Zero is returned if either difficulty is
+// missing or the parent difficulty is zero.
+func (h *Header) DifficultyRatio(parent *Header) float64 {
+	if h.Difficulty == nil || parent == nil || parent.Difficulty == nil || parent.Difficulty.Sign() == 0 {
+		return 0
+	}
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(h.Difficulty), new(big.Float).SetInt(parent.Difficulty)).Float64()
+	return ratio
+}
+
+// TimeTime returns the timestamp of the header as a UTC time.
+func (h *Header) TimeTime() time.Time {
+	return time.Unix(int64(h.Time), 0).UTC()
+}
+
+// ClampTimeTo raises the timestamp of the header to minTime if it is below it,
+// ensuring a sealed block never goes back in time relative to its parent.
+func (h *Header) ClampTimeTo(minTime uint64) {
+	if h.Time < minTime {
+		h.Time = minTime
+	}
+}
+
+// ClampDifficulty lowers the difficulty of the header to max if it is above it.
+// Nothing is done if either the difficulty or the cap is nil.
+func (h *Header) ClampDifficulty(max *big.Int) {
+	if h.Difficulty != nil && max != nil && h.Difficulty.Cmp(max) > 0 {
+		h.Difficulty = new(big.Int).Set(max)
+	}
+}
+
+// BlockTime returns the time elapsed between the parent block and this one. An
+// error is returned if the header's timestamp is not after the parent's.
+func (h *Header) BlockTime(parent *Header) (time.Duration, error) {
+	if h.Time <= parent.Time {
+		return 0, fmt.Errorf("timestamp %d not after parent timestamp %d", h.Time, parent.Time)
+	}
+	return time.Duration(h.Time-parent.Time) * time.Second, nil
+}
+
+// NextGasLimit returns the gas limit of a child block moving from the header's gas
+// limit towards target, adjusting by less than GasLimit/divisor as required by the
+// gas limit validation rule (divisor is params.GasLimitBoundDivisor on mainnet).
+// A zero divisor allows no adjustment, so the gas limit is returned unchanged.
+func (h *Header) NextGasLimit(target, divisor uint64) uint64 {
+	if divisor == 0 {
+		return h.GasLimit
+	}
+	delta := h.GasLimit / divisor
+	if delta > 0 {
+		delta--
+	}
+	switch {
+	case h.GasLimit < target:
+		if target-h.GasLimit < delta {
+			return target
+		}
+		return h.GasLimit + delta
+	case h.GasLimit > target:
+		if h.GasLimit-target < delta {
+			return target
+		}
+		return h.GasLimit - delta
+	default:
+		return h.GasLimit
+	}
+}
+
+// Heuristics used by LooksLikeTestnet.
+const (
+	// testnetDifficultyMax is the non-zero difficulty below which a header is
+	// assumed to belong to a test network. Proof-of-work mainnet never went below
+	// the 2^34 genesis difficulty, while clique and dev networks use difficulties
+	// of 1 or 2. Post-merge headers of every network have zero difficulty, which
+	// says nothing about the network and is not taken into account.
+	testnetDifficultyMax = 1 << 20
+
+	// testnetCliqueExtraLen is the extra-data length of a sealed clique header
+	// without signer list (32 bytes vanity + 65 bytes seal). Proof-of-authority
+	// is used by test networks only.
+	testnetCliqueExtraLen = 32 + 65
+)
+
+// LooksLikeTestnet is a best-effort classifier reporting whether the header most
+// likely belongs to a test network, based on its difficulty and extra-data. It is
+// meant for tooling such as importers and must not be used for consensus.
+func (h *Header) LooksLikeTestnet() bool {
+	if h.Difficulty != nil && h.Difficulty.Sign() > 0 && h.Difficulty.Cmp(big.NewInt(testnetDifficultyMax)) < 0 {
+		return true
+	}
+	return len(h.Extra) == testnetCliqueExtraLen
+}
+
 // Body is a simple (mutable, non-safe) data container for storing and moving
 // a block's data contents (transactions and uncles) together.
 type Body struct {
@@ -162,6 +447,46 @@ type Body struct {
 	Uncles       []*Header
 }
 
+// maxDecodeBodies is the maximum number of bodies accepted by DecodeBodies.
+const maxDecodeBodies = 1024
+
+// EncodeBodies writes the RLP encoding of a list of block bodies to w.
+func EncodeBodies(w io.Writer, bodies []*Body) error {
+	return rlp.Encode(w, bodies)
+}
+
+// DecodeBodies reads an RLP encoded list of block bodies from r, rejecting lists
+// of more than maxDecodeBodies entries. At most inputLimit bytes are read from r,
+// which bounds the memory used while decoding. A zero inputLimit leaves readers of
+// unknown size unbounded, in which case the caller must bound r itself.
+func DecodeBodies(r io.Reader, inputLimit uint64) ([]*Body, error) {
+	s := rlp.NewStream(r, inputLimit)
+	if _, err := s.List(); err != nil {
+		return nil, err
+	}
+	var bodies []*Body
+	for s.MoreDataInList() {
+		if len(bodies) == maxDecodeBodies {
+			return nil, fmt.Errorf("too many bodies: more than %d", maxDecodeBodies)
+		}
+		body := new(Body)
+		if err := s.Decode(body); err != nil {
+			return nil, fmt.Errorf("body %d: %v", len(bodies), err)
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, s.ListEnd()
+}
+
+// Chainable is the minimal view of a block needed to link it into a chain.
+type Chainable interface {
+	Hash() common.Hash
+	ParentHash() common.Hash
+	NumberU64() uint64
+}
+
+var _ Chainable = (*Block)(nil)
+
 // Block represents an entire block in the Ethereum blockchain.
 type Block struct {
 	header       *Header
@@ -178,6 +503,14 @@ type Block struct {
 	ReceivedFrom interface{}
 }
 
+// Checkpoint is the trimmed down representation of a block persisted in checkpoint
+// databases: its position, identity and the total difficulty of the chain up to it.
+type Checkpoint struct {
+	Number uint64
+	Hash   common.Hash
+	TD     *big.Int
+}
+
 // "external" block encoding. used for eth protocol, etc.
 type extblock struct {
 	Header *Header
@@ -231,6 +564,24 @@ func NewBlockWithHeader(header *Header) *Block {
 	return &Block{header: CopyHeader(header)}
 }
 
+// NewGenesisBlock creates an empty block at height zero with no parent. The
+// transaction, uncle and receipt hashes are set to those of empty lists. The
+// timestamp is taken as a parameter so the resulting hash is reproducible.
+func NewGenesisBlock(coinbase common.Address, difficulty *big.Int, gasLimit uint64, timestamp uint64, extra []byte) *Block {
+	return NewBlockWithHeader(&Header{
+		ParentHash:  common.Hash{},
+		UncleHash:   EmptyUncleHash,
+		Coinbase:    coinbase,
+		TxHash:      EmptyRootHash,
+		ReceiptHash: EmptyRootHash,
+		Difficulty:  difficulty,
+		Number:      new(big.Int),
+		GasLimit:    gasLimit,
+		Time:        timestamp,
+		Extra:       extra,
+	})
+}
+
 // CopyHeader creates a deep copy of a block header to prevent side effects from
 // modifying a header variable.
 func CopyHeader(h *Header) *Header {
@@ -251,6 +602,113 @@ func CopyHeader(h *Header) *Header {
 	return &cpy
 }
 
+// Copy returns a deep copy of the header, see CopyHeader.
+func (h *Header) Copy() *Header {
+	return CopyHeader(h)
+}
+
+// CopyHeaderFrom creates a deep copy of the template header and applies the given
+// overrides to the copy. The template is left untouched, so the overrides are free
+// to modify any field (including big integers) in place.
+func CopyHeaderFrom(template *Header, overrides func(*Header)) *Header {
+	cpy := CopyHeader(template)
+	if overrides != nil {
+		overrides(cpy)
+	}
+	return cpy
+}
+
+// Equal reports whether the two headers are identical field by field, comparing
+// big integers by value. Unlike comparing hashes, it does not need to hash either
+// header.
+func (h *Header) Equal(other *Header) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+	return h.ParentHash == other.ParentHash &&
+		h.UncleHash == other.UncleHash &&
+		h.Coinbase == other.Coinbase &&
+		h.Root == other.Root &&
+		h.TxHash == other.TxHash &&
+		h.ReceiptHash == other.ReceiptHash &&
+		h.Bloom == other.Bloom &&
+		bigEqual(h.Difficulty, other.Difficulty) &&
+		bigEqual(h.Number, other.Number) &&
+		h.GasLimit == other.GasLimit &&
+		h.GasUsed == other.GasUsed &&
+		h.Time == other.Time &&
+		bytes.Equal(h.Extra, other.Extra) &&
+		h.MixDigest == other.MixDigest &&
+		h.Nonce == other.Nonce &&
+		bigEqual(h.BaseFee, other.BaseFee)
+}
+
+// DiffHeaders returns a human readable description of every field that differs
+// between the two headers, in RLP field order. Big integers are compared by value.
+func DiffHeaders(a, b *Header) []string {
+	var diffs []string
+	add := func(field string, x, y interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%s: %v != %v", field, x, y))
+	}
+	if a.ParentHash != b.ParentHash {
+		add("ParentHash", a.ParentHash, b.ParentHash)
+	}
+	if a.UncleHash != b.UncleHash {
+		add("UncleHash", a.UncleHash, b.UncleHash)
+	}
+	if a.Coinbase != b.Coinbase {
+		add("Coinbase", a.Coinbase, b.Coinbase)
+	}
+	if a.Root != b.Root {
+		add("Root", a.Root, b.Root)
+	}
+	if a.TxHash != b.TxHash {
+		add("TxHash", a.TxHash, b.TxHash)
+	}
+	if a.ReceiptHash != b.ReceiptHash {
+		add("ReceiptHash", a.ReceiptHash, b.ReceiptHash)
+	}
+	if a.Bloom != b.Bloom {
+		add("Bloom", hexutil.Bytes(a.Bloom[:]), hexutil.Bytes(b.Bloom[:]))
+	}
+	if !bigEqual(a.Difficulty, b.Difficulty) {
+		add("Difficulty", a.Difficulty, b.Difficulty)
+	}
+	if !bigEqual(a.Number, b.Number) {
+		add("Number", a.Number, b.Number)
+	}
+	if a.GasLimit != b.GasLimit {
+		add("GasLimit", a.GasLimit, b.GasLimit)
+	}
+	if a.GasUsed != b.GasUsed {
+		add("GasUsed", a.GasUsed, b.GasUsed)
+	}
+	if a.Time != b.Time {
+		add("Time", a.Time, b.Time)
+	}
+	if !bytes.Equal(a.Extra, b.Extra) {
+		add("Extra", hexutil.Bytes(a.Extra), hexutil.Bytes(b.Extra))
+	}
+	if a.MixDigest != b.MixDigest {
+		add("MixDigest", a.MixDigest, b.MixDigest)
+	}
+	if a.Nonce != b.Nonce {
+		add("Nonce", hexutil.Bytes(a.Nonce[:]), hexutil.Bytes(b.Nonce[:]))
+	}
+	if !bigEqual(a.BaseFee, b.BaseFee) {
+		add("BaseFee", a.BaseFee, b.BaseFee)
+	}
+	return diffs
+}
+
+// bigEqual reports whether two possibly nil big integers hold the same value.
+func bigEqual(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Cmp(y) == 0
+}
+
 // DecodeRLP decodes the Ethereum
 func (b *Block) DecodeRLP(s *rlp.Stream) error {
 	var eb extblock
@@ -286,13 +744,31 @@ func (b *Block) Transaction(hash common.Hash) *Transaction {
 	return nil
 }
 
+// ReceiptForTx returns the receipt of the transaction with the given hash from
+// the receipts of the block, or nil if the block has no such transaction or the
+// receipts do not line up with the transactions. Receipts are matched by their
+// position, so they need not have their derived fields set.
+func (b *Block) ReceiptForTx(receipts Receipts, txHash common.Hash) *Receipt {
+	if b.checkReceipts(receipts) != nil {
+		return nil
+	}
+	for i, tx := range b.transactions {
+		if tx.Hash() == txHash {
+			return receipts[i]
+		}
+	}
+	return nil
+}
+
 func (b *Block) Number() *big.Int     { return new(big.Int).Set(b.header.Number) }
 func (b *Block) GasLimit() uint64     { return b.header.GasLimit }
 func (b *Block) GasUsed() uint64      { return b.header.GasUsed }
 func (b *Block) Difficulty() *big.Int { return new(big.Int).Set(b.header.Difficulty) }
 func (b *Block) Time() uint64         { return b.header.Time }
 
-func (b *Block) NumberU64() uint64        { return b.header.Number.Uint64() }
+// TimeTime returns the timestamp of the block as a UTC time, see Header.TimeTime.
+func (b *Block) TimeTime() time.Time { return b.header.TimeTime() }
+
 func (b *Block) MixDigest() common.Hash   { return b.header.MixDigest }
 func (b *Block) Nonce() uint64            { return binary.BigEndian.Uint64(b.header.Nonce[:]) }
 func (b *Block) Bloom() Bloom             { return b.header.Bloom }
@@ -304,6 +780,15 @@ func (b *Block) ReceiptHash() common.Hash { return b.header.ReceiptHash }
 func (b *Block) UncleHash() common.Hash   { return b.header.UncleHash }
 func (b *Block) Extra() []byte            { return common.CopyBytes(b.header.Extra) }
 
+// NumberU64 returns the block number as a uint64, or zero if the header has no
+// number set.
+func (b *Block) NumberU64() uint64 {
+	if b.header.Number == nil {
+		return 0
+	}
+	return b.header.Number.Uint64()
+}
+
 func (b *Block) BaseFee() *big.Int {
 	if b.header.BaseFee == nil {
 		return nil
@@ -316,6 +801,13 @@ func (b *Block) Header() *Header { return CopyHeader(b.header) }
 // Body returns the non-header content of the block.
 func (b *Block) Body() *Body { return &Body{b.transactions, b.uncles} }
 
+// BodyFingerprint returns the hash of the RLP encoding of the block body. Blocks
+// with identical transactions and uncles share the same fingerprint regardless
+// of their headers.
+func (b *Block) BodyFingerprint() common.Hash {
+	return rlpHash(b.Body())
+}
+
 // Size returns the true RLP encoded storage size of the block, either by encoding
 // and returning it, or returning a previsouly cached value.
 func (b *Block) Size() common.StorageSize {
@@ -328,10 +820,549 @@ func (b *Block) Size() common.StorageSize {
 	return common.StorageSize(c)
 }
 
+// DistinctSenders returns the number of unique accounts sending transactions in
+// the block. Senders are recovered with the given signer, which caches them in
+// the transactions for later use.
+func (b *Block) DistinctSenders(signer Signer) (int, error) {
+	senders := make(map[common.Address]struct{})
+	for i, tx := range b.transactions {
+		from, err := Sender(signer, tx)
+		if err != nil {
+			return 0, fmt.Errorf("transaction %d: invalid sender: %v", i, err)
+		}
+		senders[from] = struct{}{}
+	}
+	return len(senders), nil
+}
+
+// SingleSender returns the sender of the block's transactions if they were all
+// sent by the same account. Senders are recovered with the given signer, which
+// caches them in the transactions. False is returned for an empty block, mixed
+// senders, or if any sender cannot be recovered.
+func (b *Block) SingleSender(signer Signer) (common.Address, bool) {
+	var sender common.Address
+	for i, tx := range b.transactions {
+		from, err := Sender(signer, tx)
+		if err != nil || (i > 0 && from != sender) {
+			return common.Address{}, false
+		}
+		sender = from
+	}
+	return sender, len(b.transactions) > 0
+}
+
+// SizeBreakdown returns the RLP encoded sizes of the header, the transaction list
+// and the uncle list of the block. Their sum equals Size minus the length prefix
+// of the outer block list.
+func (b *Block) SizeBreakdown() (header, txs, uncles common.StorageSize) {
+	var hc, tc, uc writeCounter
+	rlp.Encode(&hc, b.header)
+	rlp.Encode(&tc, b.transactions)
+	rlp.Encode(&uc, b.uncles)
+	return common.StorageSize(hc), common.StorageSize(tc), common.StorageSize(uc)
+}
+
 // SanityCheck can be used to prevent that unbounded fields are
 // stuffed with junk data to add processing overhead
 func (b *Block) SanityCheck() error {
-	return b.header.SanityCheck()
+	if err := b.header.SanityCheck(); err != nil {
+		return err
+	}
+	if !b.GenesisConsistent() {
+		if b.header.ParentHash == (common.Hash{}) {
+			return fmt.Errorf("missing parent hash for block %d", b.NumberU64())
+		}
+		return fmt.Errorf("genesis block with parent hash %x", b.header.ParentHash)
+	}
+	return nil
+}
+
+// ParentLinkValid reports whether the block references a parent. Only the
+// genesis block may have a zero parent hash. A block without number is invalid.
+func (b *Block) ParentLinkValid() bool {
+	if b.header.Number == nil {
+		return false
+	}
+	return b.header.Number.Sign() == 0 || b.header.ParentHash != (common.Hash{})
+}
+
+// UncleNumbersValid checks that every uncle of the block is numbered within the
+// window [number-window, number-1] preceding the block. The lower bound saturates
+// at the genesis block. An uncle without a number is rejected.
+func (b *Block) UncleNumbersValid(window uint64) error {
+	number := b.NumberU64()
+	min := uint64(0)
+	if number > window {
+		min = number - window
+	}
+	for i, uncle := range b.uncles {
+		if uncle.Number == nil {
+			return fmt.Errorf("uncle %d (%x): missing number", i, uncle.Hash())
+		}
+		if !uncle.Number.IsUint64() || uncle.Number.Uint64() >= number || uncle.Number.Uint64() < min {
+			return fmt.Errorf("uncle %d (%x): number %v outside of [%d, %d]", i, uncle.Hash(), uncle.Number, min, int64(number)-1)
+		}
+	}
+	return nil
+}
+
+// GenesisConsistent reports whether the block has a zero parent hash exactly when
+// it is numbered zero. It is stricter than ParentLinkValid, also rejecting genesis
+// blocks that claim a parent. A block without number is inconsistent.
+func (b *Block) GenesisConsistent() bool {
+	if b.header.Number == nil {
+		return false
+	}
+	return (b.header.ParentHash == common.Hash{}) == (b.header.Number.Sign() == 0)
+}
+
+// TransactionsNonceOrdered checks that the transactions of each sender appear in
+// the block with strictly increasing nonces. Senders are recovered with the given
+// signer, which caches them in the transactions for later use.
+func (b *Block) TransactionsNonceOrdered(signer Signer) error {
+	nonces := make(map[common.Address]uint64)
+	for i, tx := range b.transactions {
+		from, err := Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("transaction %d: invalid sender: %v", i, err)
+		}
+		if prev, ok := nonces[from]; ok && tx.Nonce() <= prev {
+			return fmt.Errorf("transaction %d: nonce %d of %x not above previous %d", i, tx.Nonce(), from, prev)
+		}
+		nonces[from] = tx.Nonce()
+	}
+	return nil
+}
+
+// IsAllContractCalls reports whether every transaction in the block is a call
+// (non-nil recipient) transferring zero value. Empty blocks return false.
+func (b *Block) IsAllContractCalls() bool {
+	if len(b.transactions) == 0 {
+		return false
+	}
+	for _, tx := range b.transactions {
+		if tx.To() == nil || tx.Value().Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TransactionTypeCounts returns the number of transactions in the block for each
+// transaction type. Legacy transactions are counted under LegacyTxType.
+func (b *Block) TransactionTypeCounts() map[uint8]int {
+	counts := make(map[uint8]int)
+	for _, tx := range b.transactions {
+		counts[tx.Type()]++
+	}
+	return counts
+}
+
+// TransactionsAboveValue returns the transactions of the block transferring more
+// than min wei, in block order. The result is non-nil even if none qualify.
+func (b *Block) TransactionsAboveValue(min *big.Int) Transactions {
+	txs := make(Transactions, 0)
+	for _, tx := range b.transactions {
+		if tx.inner.value().Cmp(min) > 0 {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// TransactionsFrom returns the transactions of the block sent by addr, in block
+// order, see Transactions.FilterFrom.
+func (b *Block) TransactionsFrom(signer Signer, addr common.Address) Transactions {
+	return b.transactions.FilterFrom(signer, addr)
+}
+
+// TransactionsTo returns the transactions of the block sent to addr, in block
+// order. Contract creations are never included. The result is non-nil even if
+// none match.
+func (b *Block) TransactionsTo(addr common.Address) Transactions {
+	txs := make(Transactions, 0)
+	for _, tx := range b.transactions {
+		if to := tx.inner.to(); to != nil && *to == addr {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+// AverageGasPrice returns the integer mean of the gas prices of the transactions
+// in the block, or nil for an empty block.
+func (b *Block) AverageGasPrice() *big.Int {
+	return b.transactions.AverageGasPrice()
+}
+
+// WouldFit reports whether a transaction using txGas gas still fits into the gas
+// limit of the block on top of the gas already used.
+func (b *Block) WouldFit(txGas uint64) bool {
+	return b.header.GasUsed <= b.header.GasLimit && txGas <= b.header.GasLimit-b.header.GasUsed
+}
+
+// ValidateGas checks that the gas used by the block does not exceed its gas limit.
+func (b *Block) ValidateGas() error {
+	if b.header.GasUsed > b.header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", b.header.GasUsed, b.header.GasLimit)
+	}
+	return nil
+}
+
+// ReceivedLag returns how long after its header timestamp the block was received,
+// according to ReceivedAt. Zero is returned if the receive time is not known.
+func (b *Block) ReceivedLag() time.Duration {
+	if b.ReceivedAt.IsZero() {
+		return 0
+	}
+	return b.ReceivedAt.Sub(b.TimeTime())
+}
+
+// InvolvesAddress reports whether the address participates in the block as the
+// coinbase, a transaction sender or recipient, or a log emitter or topic (as a
+// left-padded 32 byte word) in the given receipts. The header bloom is used to
+// skip scanning the logs when the address cannot be present.
+func (b *Block) InvolvesAddress(signer Signer, receipts Receipts, addr common.Address) bool {
+	if b.header.Coinbase == addr {
+		return true
+	}
+	for _, tx := range b.transactions {
+		if to := tx.To(); to != nil && *to == addr {
+			return true
+		}
+		if from, err := Sender(signer, tx); err == nil && from == addr {
+			return true
+		}
+	}
+	topic := common.BytesToHash(addr.Bytes())
+	if !b.header.Bloom.Test(addr.Bytes()) && !b.header.Bloom.Test(topic.Bytes()) {
+		return false
+	}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if log.Address == addr {
+				return true
+			}
+			for _, t := range log.Topics {
+				if t == topic {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// TransactionsRLP returns the RLP encoding of the block's transaction list.
+func (b *Block) TransactionsRLP() ([]byte, error) {
+	return rlp.EncodeToBytes(b.transactions)
+}
+
+// UncleRLP returns the RLP encoding of the uncle header at index i. Its keccak256
+// hash is the hash of the uncle.
+func (b *Block) UncleRLP(i int) ([]byte, error) {
+	if i < 0 || i >= len(b.uncles) {
+		return nil, fmt.Errorf("uncle index %d out of range [0, %d)", i, len(b.uncles))
+	}
+	return rlp.EncodeToBytes(b.uncles[i])
+}
+
+// DecodeTransactionsRLP decodes an RLP encoded transaction list, as produced by
+// Block.TransactionsRLP.
+func DecodeTransactionsRLP(data []byte) (Transactions, error) {
+	var txs Transactions
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// TimestampStrictlyAfter reports whether the block's timestamp is strictly greater
+// than that of the given parent. A timestamp equal to the parent's is rejected.
+func (b *Block) TimestampStrictlyAfter(parent *Header) bool {
+	return b.header.Time > parent.Time
+}
+
+// StateChanged reports whether the state root of the block differs from that of
+// the given parent. A block carrying transactions but leaving the state root
+// unchanged is suspicious. Note that an empty block may still change the state,
+// e.g. by crediting mining rewards.
+func (b *Block) StateChanged(parent *Header) bool {
+	return b.header.Root != parent.Root
+}
+
+// DedupKey returns a fixed size key identifying the block, made up of its 8 byte
+// big endian number followed by its hash. It is meant to key caches of seen blocks
+// across forks.
+func (b *Block) DedupKey() (key [40]byte) {
+	binary.BigEndian.PutUint64(key[:8], b.NumberU64())
+	hash := b.Hash()
+	copy(key[8:], hash[:])
+	return key
+}
+
+// BlocksBehind returns how many blocks the block lags behind the given head. The
+// boolean is false if the block is ahead of the head, or if either number is
+// missing, in which case the distance is zero.
+func (b *Block) BlocksBehind(head *Header) (uint64, bool) {
+	if b.header.Number == nil || head == nil || head.Number == nil {
+		return 0, false
+	}
+	if b.header.Number.Cmp(head.Number) > 0 {
+		return 0, false
+	}
+	return new(big.Int).Sub(head.Number, b.header.Number).Uint64(), true
+}
+
+// TxDiff returns the transactions dropped and added when replacing oldBlock with
+// newBlock, compared by hash and preserving block order.
+func TxDiff(oldBlock, newBlock *Block) (dropped, added Transactions) {
+	return TxDifference(oldBlock.transactions, newBlock.transactions), TxDifference(newBlock.transactions, oldBlock.transactions)
+}
+
+// SameChainPosition reports whether the two blocks occupy the same slot in the
+// chain, having the same number and parent. Such blocks are either duplicates or
+// siblings; compare their hashes to tell them apart. Blocks without a number are
+// never in the same position.
+func (b *Block) SameChainPosition(other *Block) bool {
+	if b.header.Number == nil || other.header.Number == nil {
+		return false
+	}
+	return b.header.Number.Cmp(other.header.Number) == 0 && b.header.ParentHash == other.header.ParentHash
+}
+
+// RangeTransactions calls fn for each transaction of the block along with its
+// index, in block order, stopping early if fn returns false.
+func (b *Block) RangeTransactions(fn func(i int, tx *Transaction) bool) {
+	for i, tx := range b.transactions {
+		if !fn(i, tx) {
+			return
+		}
+	}
+}
+
+// TxSizeHistogram counts the transactions of the block by their encoded size.
+// The buckets are ascending lower bounds in bytes: bucket i holds transactions of
+// size in [buckets[i], buckets[i+1]) and the last bucket is open ended. Transactions
+// smaller than buckets[0] are not counted.
+func (b *Block) TxSizeHistogram(buckets []int) []int {
+	counts := make([]int, len(buckets))
+	for _, tx := range b.transactions {
+		size := int(tx.Size())
+		if i := sort.SearchInts(buckets, size+1) - 1; i >= 0 {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// HighestGasPriceTx returns the transaction offering the highest gas price in the
+// block, or nil if the block has no transactions. Among transactions with the same
+// price, the earliest one is returned.
+func (b *Block) HighestGasPriceTx() *Transaction {
+	var best *Transaction
+	for _, tx := range b.transactions {
+		if best == nil || tx.inner.gasPrice().Cmp(best.inner.gasPrice()) > 0 {
+			best = tx
+		}
+	}
+	return best
+}
+
+// checkReceipts ensures that the given receipts line up with the transactions of
+// the block, one receipt per transaction.
+func (b *Block) checkReceipts(receipts Receipts) error {
+	if len(receipts) != len(b.transactions) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(b.transactions))
+	}
+	return nil
+}
+
+// effectiveGasPrice returns the price per gas actually paid by the transaction
+// when included in the block.
+func (b *Block) effectiveGasPrice(tx *Transaction) *big.Int {
+	if b.header.BaseFee == nil {
+		return tx.GasPrice()
+	}
+	return cmath.BigMin(new(big.Int).Add(tx.GasTipCap(), b.header.BaseFee), tx.GasFeeCap())
+}
+
+// TotalFees returns the sum of the fees paid by all transactions in the block,
+// using the given receipts to determine the gas used by each transaction. An
+// error is returned if the receipts are misaligned or their cumulative gas used
+// decreases.
+func (b *Block) TotalFees(receipts Receipts) (*big.Int, error) {
+	return b.CoinbaseGasReward(receipts, b.effectiveGasPrice)
+}
+
+// CoinbaseGasReward returns the sum of the gas used by each transaction times the
+// price returned for it by gasPriceOf. Only the cumulative gas used of the given
+// receipts is consulted, so receipts stripped of their logs are fine. An error is
+// returned if the cumulative gas used ever decreases.
+func (b *Block) CoinbaseGasReward(receipts Receipts, gasPriceOf func(*Transaction) *big.Int) (*big.Int, error) {
+	if err := b.checkReceipts(receipts); err != nil {
+		return nil, err
+	}
+	if err := receipts.checkCumulativeGas(); err != nil {
+		return nil, err
+	}
+	var (
+		total = new(big.Int)
+		prev  uint64
+	)
+	for i, tx := range b.transactions {
+		used := receipts[i].CumulativeGasUsed - prev
+		prev = receipts[i].CumulativeGasUsed
+
+		fee := new(big.Int).SetUint64(used)
+		total.Add(total, fee.Mul(fee, gasPriceOf(tx)))
+	}
+	return total, nil
+}
+
+// TotalMinerReward returns the total amount credited to the block's coinbase:
+// the given base block reward, the inclusion bonus of base/32 per uncle, and the
+// priority fees paid by the transactions. Post-London, the base fee portion of
+// the fees is burnt and not counted. The receipts are needed to determine the
+// gas used by each transaction.
+func (b *Block) TotalMinerReward(base *big.Int, receipts Receipts) (*big.Int, error) {
+	reward, err := b.CoinbaseGasReward(receipts, b.effectiveTip)
+	if err != nil {
+		return nil, err
+	}
+	reward.Add(reward, base)
+	bonus := new(big.Int).Div(base, big.NewInt(32))
+	for range b.uncles {
+		reward.Add(reward, bonus)
+	}
+	return reward, nil
+}
+
+// effectiveTip returns the price per gas of the transaction credited to the
+// coinbase, i.e. the effective gas price minus the burnt base fee.
+func (b *Block) effectiveTip(tx *Transaction) *big.Int {
+	tip := b.effectiveGasPrice(tx)
+	if b.header.BaseFee != nil {
+		tip = new(big.Int).Sub(tip, b.header.BaseFee)
+	}
+	return tip
+}
+
+// ReceiptStatusCounts returns the number of successful and failed transactions
+// in the block according to the given receipts. Pre-Byzantium receipts, which
+// carry an intermediate state root instead of a status, are not counted.
+func (b *Block) ReceiptStatusCounts(receipts Receipts) (success, failed int, err error) {
+	if err := b.checkReceipts(receipts); err != nil {
+		return 0, 0, err
+	}
+	for _, receipt := range receipts {
+		switch {
+		case len(receipt.PostState) > 0:
+		case receipt.Status == ReceiptStatusSuccessful:
+			success++
+		default:
+			failed++
+		}
+	}
+	return success, failed, nil
+}
+
+// Checkpoint returns the checkpoint entry of the block. The total difficulty is not
+// tracked by blocks, so it has to be provided by the caller; a nil td leaves the
+// total difficulty of the checkpoint unset.
+func (b *Block) Checkpoint(td *big.Int) Checkpoint {
+	cp := Checkpoint{Number: b.NumberU64(), Hash: b.Hash()}
+	if td != nil {
+		cp.TD = new(big.Int).Set(td)
+	}
+	return cp
+}
+
+// BlockEnvelopeVersion is the current version of the BlockEnvelope format.
+const BlockEnvelopeVersion = 1
+
+// BlockEnvelope wraps a block with the metadata needed to share it between tools
+// in a self-describing way.
+type BlockEnvelope struct {
+	Version   uint8
+	NetworkID uint64
+	Block     *Block
+}
+
+// Envelope wraps the block into a BlockEnvelope of the current version.
+func (b *Block) Envelope(networkID uint64) *BlockEnvelope {
+	return &BlockEnvelope{Version: BlockEnvelopeVersion, NetworkID: networkID, Block: b}
+}
+
+// DecodeRLP implements rlp.Decoder, rejecting envelopes of unknown versions
+// before decoding the block.
+func (e *BlockEnvelope) DecodeRLP(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	version, err := s.Uint8()
+	if err != nil {
+		return err
+	}
+	if version != BlockEnvelopeVersion {
+		return fmt.Errorf("unsupported block envelope version %d", version)
+	}
+	networkID, err := s.Uint64()
+	if err != nil {
+		return err
+	}
+	block := new(Block)
+	if err := s.Decode(block); err != nil {
+		return err
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	e.Version, e.NetworkID, e.Block = version, networkID, block
+	return nil
+}
+
+// PartitionByStatus splits the transactions of the block by the execution status
+// recorded in the given receipts, preserving block order within each group. As in
+// ReceiptStatusCounts, transactions with pre-Byzantium receipts, which carry an
+// intermediate state root instead of a status, are in neither group.
+func (b *Block) PartitionByStatus(receipts Receipts) (succeeded, reverted Transactions, err error) {
+	if err := b.checkReceipts(receipts); err != nil {
+		return nil, nil, err
+	}
+	for i, tx := range b.transactions {
+		switch {
+		case len(receipts[i].PostState) > 0:
+		case receipts[i].Status == ReceiptStatusSuccessful:
+			succeeded = append(succeeded, tx)
+		default:
+			reverted = append(reverted, tx)
+		}
+	}
+	return succeeded, reverted, nil
+}
+
+// GasUsedConsistent checks that the gas used recorded in the header equals the sum
+// of the gas used by each transaction, as derived from the given receipts.
+func (b *Block) GasUsedConsistent(receipts Receipts) error {
+	if err := b.checkReceipts(receipts); err != nil {
+		return err
+	}
+	return receipts.ValidateCumulativeGas(b.header.GasUsed)
+}
+
+// Logs returns the logs of all the given receipts flattened in block order, so
+// the position of a log in the result is its index within the block. Nil is
+// returned if the receipts do not belong to the block's transactions.
+func (b *Block) Logs(receipts Receipts) []*Log {
+	if b.checkReceipts(receipts) != nil {
+		return nil
+	}
+	var logs []*Log
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	return logs
 }
 
 type writeCounter common.StorageSize
@@ -341,6 +1372,9 @@ func (c *writeCounter) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// CalcUncleHash computes the uncle commitment of a block header. Unlike the
+// transaction and receipt roots, which are tries built by DeriveSha, the consensus
+// rule for uncles is the plain keccak256 hash of the RLP encoded uncle list.
 func CalcUncleHash(uncles []*Header) common.Hash {
 	if len(uncles) == 0 {
 		return EmptyUncleHash
@@ -360,6 +1394,77 @@ func (b *Block) WithSeal(header *Header) *Block {
 	}
 }
 
+// deriveReceiptHash computes the receipt trie root the same way NewBlock does.
+func deriveReceiptHash(receipts Receipts, hasher TrieHasher) common.Hash {
+	if len(receipts) == 0 {
+		return EmptyRootHash
+	}
+	return DeriveSha(receipts, hasher)
+}
+
+// ReceiptHashMatches reports whether the receipt root in the header commits to
+// the given receipts.
+func (b *Block) ReceiptHashMatches(receipts Receipts, hasher TrieHasher) bool {
+	return b.header.ReceiptHash == deriveReceiptHash(receipts, hasher)
+}
+
+// BloomValid reports whether the bloom in the header is the one derived from the
+// logs of the given receipts.
+func (b *Block) BloomValid(receipts Receipts) bool {
+	return b.header.Bloom == CreateBloom(receipts)
+}
+
+// BloomCoversAllLogs reports whether the address and every topic of each log in
+// the given receipts test positive against the bloom in the header. Unlike
+// BloomValid, it tolerates a bloom with extra bits set, but catches any log that
+// a bloom filter lookup would miss.
+func (b *Block) BloomCoversAllLogs(receipts Receipts) bool {
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if !b.header.Bloom.Test(log.Address.Bytes()) {
+				return false
+			}
+			for _, topic := range log.Topics {
+				if !b.header.Bloom.Test(topic.Bytes()) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// RefreshReceiptHeader returns a new block with the receipt root and bloom of the
+// header recomputed from the given receipts. It is meant to be used after the
+// receipts of a block were changed; the original block is left untouched.
+func (b *Block) RefreshReceiptHeader(receipts Receipts, hasher TrieHasher) *Block {
+	header := CopyHeader(b.header)
+	header.ReceiptHash = deriveReceiptHash(receipts, hasher)
+	header.Bloom = CreateBloom(receipts)
+
+	return b.WithSeal(header)
+}
+
+// RebuildHeaderRoots returns a new block with all header fields derived from the
+// body recomputed: the transaction root, the uncle hash and, if receipts are
+// given, the receipt root and bloom. A nil receipt list leaves the latter two as
+// they are. The original block, including its cached hash, is left untouched.
+func (b *Block) RebuildHeaderRoots(receipts Receipts, hasher TrieHasher) *Block {
+	header := CopyHeader(b.header)
+	if len(b.transactions) == 0 {
+		header.TxHash = EmptyRootHash
+	} else {
+		header.TxHash = DeriveSha(b.transactions, hasher)
+	}
+	header.UncleHash = CalcUncleHash(b.uncles)
+
+	block := b.WithSeal(header)
+	if receipts != nil {
+		block = block.RefreshReceiptHeader(receipts, hasher)
+	}
+	return block
+}
+
 // WithBody returns a new block with the given transaction and uncle contents.
 func (b *Block) WithBody(transactions []*Transaction, uncles []*Header) *Block {
 	block := &Block{
@@ -374,6 +1479,17 @@ func (b *Block) WithBody(transactions []*Transaction, uncles []*Header) *Block {
 	return block
 }
 
+// DeepCopy returns a copy of the block that shares no mutable state with it: the
+// header and uncles are deep copied and the transaction list is duplicated. The
+// transactions themselves are immutable and thus shared. The relay metadata is
+// carried over.
+func (b *Block) DeepCopy() *Block {
+	cpy := b.WithBody(b.transactions, b.uncles)
+	cpy.ReceivedAt = b.ReceivedAt
+	cpy.ReceivedFrom = b.ReceivedFrom
+	return cpy
+}
+
 // Hash returns the keccak256 hash of b's header.
 // The hash is computed on the first call and cached thereafter.
 func (b *Block) Hash() common.Hash {
@@ -385,8 +1501,210 @@ func (b *Block) Hash() common.Hash {
 	return v
 }
 
+// SealHash returns the hash of the block header excluding the MixDigest and Nonce
+// seal fields, i.e. the input proof-of-work sealing is computed over. It is the
+// same as Header.HashNoNonce.
+func (b *Block) SealHash() common.Hash {
+	return b.header.HashNoNonce()
+}
+
+// ReorgDepth returns the number of blocks rolled back when switching the chain
+// head from oldHead to newHead, i.e. the distance from oldHead to the common
+// ancestor of the two. Headers are resolved through parentOf, which should return
+// nil for unknown hashes. At most maxDepth headers are resolved in total, counting
+// the steps on both sides, so a new head far ahead of the old one is bounded too.
+// An error is returned if a header lacks its number, an ancestor cannot be
+// resolved or the bound is exceeded.
+func ReorgDepth(oldHead, newHead *Header, parentOf func(common.Hash) *Header, maxDepth int) (int, error) {
+	depth, steps := 0, 0
+	for {
+		if oldHead.Number == nil || newHead.Number == nil {
+			return 0, errors.New("missing block number")
+		}
+		if oldHead.Hash() == newHead.Hash() {
+			return depth, nil
+		}
+		oldNum, newNum := oldHead.Number.Uint64(), newHead.Number.Uint64()
+		if oldNum >= newNum {
+			if steps == maxDepth {
+				return 0, fmt.Errorf("reorg deeper than %d blocks", maxDepth)
+			}
+			parent := parentOf(oldHead.ParentHash)
+			if parent == nil {
+				return 0, fmt.Errorf("unknown ancestor %x of old head", oldHead.ParentHash)
+			}
+			oldHead = parent
+			depth++
+			steps++
+		}
+		if newNum >= oldNum {
+			if steps == maxDepth {
+				return 0, fmt.Errorf("reorg deeper than %d blocks", maxDepth)
+			}
+			parent := parentOf(newHead.ParentHash)
+			if parent == nil {
+				return 0, fmt.Errorf("unknown ancestor %x of new head", newHead.ParentHash)
+			}
+			newHead = parent
+			steps++
+		}
+	}
+}
+
+// SkeletonChecksum returns the keccak256 hash of the concatenated hashes of the
+// given skeleton headers. The headers are expected to be the evenly spaced
+// skeleton of a header range (e.g. every 192nd header, as requested by the
+// downloader) in ascending order; the spacing is not verified, but any change in
+// the selection or order of the headers changes the checksum.
+func SkeletonChecksum(headers []*Header) (h common.Hash) {
+	sha := hasherPool.Get().(crypto.KeccakState)
+	defer hasherPool.Put(sha)
+	sha.Reset()
+	for _, header := range headers {
+		hash := header.Hash()
+		sha.Write(hash[:])
+	}
+	sha.Read(h[:])
+	return h
+}
+
 type Blocks []*Block
 
+// FilterByTimeRange returns the blocks whose timestamp falls within [from, to],
+// preserving their order. The result is non-nil even if no block matches.
+func (blocks Blocks) FilterByTimeRange(from, to time.Time) Blocks {
+	filtered := make(Blocks, 0)
+	for _, b := range blocks {
+		if ts := b.TimeTime(); !ts.Before(from) && !ts.After(to) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
+// CumulativeGasUsed returns the running total of gas used across the blocks, in
+// the order of the batch.
+func (blocks Blocks) CumulativeGasUsed() []uint64 {
+	var (
+		totals = make([]uint64, len(blocks))
+		total  uint64
+	)
+	for i, b := range blocks {
+		total += b.GasUsed()
+		totals[i] = total
+	}
+	return totals
+}
+
+// TotalTransactions returns the number of transactions across all blocks.
+func (blocks Blocks) TotalTransactions() int {
+	total := 0
+	for _, b := range blocks {
+		total += len(b.transactions)
+	}
+	return total
+}
+
+// TotalEncodedSize returns the combined size of the network RLP encodings of the
+// blocks, measured without retaining the encoded bytes.
+func (blocks Blocks) TotalEncodedSize() (int, error) {
+	var c writeCounter
+	for _, b := range blocks {
+		if err := rlp.Encode(&c, b); err != nil {
+			return 0, err
+		}
+	}
+	return int(c), nil
+}
+
+// GasLimitVolatility returns the population standard deviation of the gas limits
+// of the blocks, zero for an empty batch. A high value relative to the mean hints
+// at miners oscillating the limit.
+func (blocks Blocks) GasLimitVolatility() float64 {
+	if len(blocks) == 0 {
+		return 0
+	}
+	var mean, variance float64
+	for _, b := range blocks {
+		mean += float64(b.header.GasLimit)
+	}
+	mean /= float64(len(blocks))
+	for _, b := range blocks {
+		d := float64(b.header.GasLimit) - mean
+		variance += d * d
+	}
+	return math.Sqrt(variance / float64(len(blocks)))
+}
+
+// BlockBy is a less function ordering two blocks, usable to sort a batch.
+type BlockBy func(b1, b2 *Block) bool
+
+// Sort sorts the given blocks in place according to the ordering function.
+func (by BlockBy) Sort(blocks Blocks) {
+	sort.Sort(blockSorter{blocks: blocks, by: by})
+}
+
+// Then returns an ordering that sorts by the receiver first, falling back to the
+// secondary ordering for blocks the receiver considers equal.
+func (by BlockBy) Then(secondary BlockBy) BlockBy {
+	return func(b1, b2 *Block) bool {
+		if by(b1, b2) {
+			return true
+		}
+		if by(b2, b1) {
+			return false
+		}
+		return secondary(b1, b2)
+	}
+}
+
+type blockSorter struct {
+	blocks Blocks
+	by     func(b1, b2 *Block) bool
+}
+
+func (s blockSorter) Len() int           { return len(s.blocks) }
+func (s blockSorter) Swap(i, j int)      { s.blocks[i], s.blocks[j] = s.blocks[j], s.blocks[i] }
+func (s blockSorter) Less(i, j int) bool { return s.by(s.blocks[i], s.blocks[j]) }
+
+// compareBig compares two header integers like big.Int.Cmp, treating a missing
+// value as lower than any set one.
+func compareBig(x, y *big.Int) int {
+	switch {
+	case x == nil && y == nil:
+		return 0
+	case x == nil:
+		return -1
+	case y == nil:
+		return 1
+	}
+	return x.Cmp(y)
+}
+
+// NumberThenHash orders blocks by ascending number, breaking ties between blocks
+// of the same height by comparing their hashes lexicographically. This gives a
+// total order over any set of distinct blocks. Blocks without a number come first.
+func NumberThenHash(b1, b2 *Block) bool {
+	if c := compareBig(b1.header.Number, b2.header.Number); c != 0 {
+		return c < 0
+	}
+	h1, h2 := b1.Hash(), b2.Hash()
+	return bytes.Compare(h1[:], h2[:]) < 0
+}
+
+// ByNumberDesc orders blocks by descending number. A missing number counts as
+// the lowest, so such blocks come last.
+func ByNumberDesc(b1, b2 *Block) bool { return compareBig(b1.header.Number, b2.header.Number) > 0 }
+
+// ByDifficulty orders blocks by ascending difficulty. A missing difficulty counts
+// as the lowest, so such blocks come first.
+func ByDifficulty(b1, b2 *Block) bool {
+	return compareBig(b1.header.Difficulty, b2.header.Difficulty) < 0
+}
+
+// ByTime orders blocks by ascending timestamp.
+func ByTime(b1, b2 *Block) bool { return b1.header.Time < b2.header.Time }
+
 // HeaderParentHashFromRLP returns the parentHash of an RLP-encoded
 // header. If 'header' is invalid, the zero hash is returned.
 func HeaderParentHashFromRLP(header []byte) common.Hash {
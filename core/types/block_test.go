@@ -18,10 +18,15 @@ package types
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"errors"
 	"hash"
+	"io"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -217,6 +222,61 @@ func BenchmarkEncodeBlock(b *testing.B) {
 	}
 }
 
+// BenchmarkHash10K compares hashing a header directly, which recomputes the hash
+// on every call, against going through the block, which caches it.
+func BenchmarkHash10K(b *testing.B) {
+	block := makeBenchBlock()
+	header := block.Header()
+
+	b.Run("header", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 10000; j++ {
+				header.Hash()
+			}
+		}
+	})
+	b.Run("block", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 10000; j++ {
+				block.Hash()
+			}
+		}
+	})
+}
+
+// BenchmarkBlockSize compares measuring a freshly assembled block, which encodes
+// it, against querying the cached size of an already measured one.
+func BenchmarkBlockSize(b *testing.B) {
+	var (
+		key, _ = crypto.GenerateKey()
+		signer = LatestSigner(params.TestChainConfig)
+		txs    = make([]*Transaction, 200)
+	)
+	for i := range txs {
+		tx, err := SignTx(NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 21000, big.NewInt(1), make([]byte, 100)), signer, key)
+		if err != nil {
+			b.Fatal(err)
+		}
+		txs[i] = tx
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, nil, newHasher())
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			(&Block{header: block.header, transactions: block.transactions}).Size()
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			block.Size()
+		}
+	})
+}
+
 // testHasher is the helper tool for transaction/receipt list hashing.
 // The original hasher is trie, in order to get rid of import cycle,
 // use the testing hasher instead.
@@ -342,3 +402,1983 @@ func TestRlpDecodeParentHash(t *testing.T) {
 		}
 	}
 }
+
+// signTestTx signs an empty value transfer with the given nonce.
+func signTestTx(t *testing.T, signer Signer, key *ecdsa.PrivateKey, nonce uint64) *Transaction {
+	tx, err := SignTx(NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// newTestTxs creates unsigned legacy transfers with consecutive nonces, one
+// for each of the given gas prices.
+func newTestTxs(prices ...int64) []*Transaction {
+	txs := make([]*Transaction, len(prices))
+	for i, price := range prices {
+		txs[i] = NewTransaction(uint64(i), common.Address{byte(i + 1)}, big.NewInt(0), 50000, big.NewInt(price), nil)
+	}
+	return txs
+}
+
+// newTestReceipts creates successful receipts aligned with newTestTxs, where
+// gasUsed holds the gas consumed by each individual transaction.
+func newTestReceipts(gasUsed ...uint64) Receipts {
+	var (
+		receipts   = make(Receipts, len(gasUsed))
+		cumulative uint64
+	)
+	for i, gas := range gasUsed {
+		cumulative += gas
+		receipts[i] = &Receipt{Status: ReceiptStatusSuccessful, CumulativeGasUsed: cumulative}
+	}
+	return receipts
+}
+
+func TestTransactionsNonceOrdered(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		signer  = HomesteadSigner{}
+	)
+	header := &Header{Number: big.NewInt(1)}
+
+	ordered := NewBlock(header, []*Transaction{signTestTx(t, signer, key1, 0), signTestTx(t, signer, key2, 5), signTestTx(t, signer, key1, 1), signTestTx(t, signer, key2, 6)}, nil, nil, newHasher())
+	if err := ordered.TransactionsNonceOrdered(signer); err != nil {
+		t.Fatalf("ordered block rejected: %v", err)
+	}
+	unordered := NewBlock(header, []*Transaction{signTestTx(t, signer, key1, 1), signTestTx(t, signer, key2, 0), signTestTx(t, signer, key1, 0)}, nil, nil, newHasher())
+	if err := unordered.TransactionsNonceOrdered(signer); err == nil {
+		t.Fatal("out-of-order block accepted")
+	} else if !strings.HasPrefix(err.Error(), "transaction 2:") {
+		t.Fatalf("wrong offending transaction: %v", err)
+	}
+}
+
+func TestCopyHeaderFrom(t *testing.T) {
+	template := &Header{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(100),
+		GasLimit:   8000000,
+		Extra:      []byte("template"),
+	}
+	want := template.Hash()
+
+	cpy := CopyHeaderFrom(template, func(h *Header) {
+		h.Number.Add(h.Number, common.Big1)
+		h.Difficulty.SetUint64(1)
+		h.Extra[0] = 'T'
+		h.GasLimit = 1
+	})
+	if cpy.Number.Uint64() != 101 || cpy.Difficulty.Uint64() != 1 || cpy.GasLimit != 1 || string(cpy.Extra) != "Template" {
+		t.Fatalf("overrides not applied: %+v", cpy)
+	}
+	if have := template.Hash(); have != want {
+		t.Fatalf("template modified: hash %x, want %x", have, want)
+	}
+}
+
+func TestSizeBreakdown(t *testing.T) {
+	block := makeBenchBlock()
+
+	header, txs, uncles := block.SizeBreakdown()
+	if header == 0 || txs == 0 || uncles == 0 {
+		t.Fatalf("empty component: header %v, txs %v, uncles %v", header, txs, uncles)
+	}
+	if txs <= uncles {
+		t.Errorf("transactions (%v) should dominate uncles (%v)", txs, uncles)
+	}
+	content := uint64(header + txs + uncles)
+	if have, want := uint64(block.Size()), rlp.ListSize(content); have != want {
+		t.Fatalf("size mismatch: block %d, components %d", have, want)
+	}
+}
+
+// Tests that a header carrying a nonce shorter than 8 bytes is rejected by the
+// decoder instead of being silently zero-padded.
+func TestHeaderShortNonceDecoding(t *testing.T) {
+	fields := func(nonce []byte) []interface{} {
+		return []interface{}{
+			common.Hash{}, EmptyUncleHash, common.Address{}, common.Hash{}, EmptyRootHash, EmptyRootHash, Bloom{},
+			big.NewInt(131072), big.NewInt(1), uint64(5000), uint64(0), uint64(1426516743), []byte{},
+			common.Hash{}, nonce,
+		}
+	}
+	enc, err := rlp.EncodeToBytes(fields(make([]byte, 8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header Header
+	if err := rlp.DecodeBytes(enc, &header); err != nil {
+		t.Fatalf("valid header rejected: %v", err)
+	}
+	enc, err = rlp.EncodeToBytes(fields(make([]byte, 7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rlp.DecodeBytes(enc, &header); err == nil {
+		t.Fatal("header with 7 byte nonce accepted")
+	} else if !strings.Contains(err.Error(), "input string too short") {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
+func TestBlockTotalFees(t *testing.T) {
+	txs := newTestTxs(10, 3)
+	receipts := newTestReceipts(21000, 30000)
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	fees, err := block.TotalFees(receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := big.NewInt(21000*10 + 30000*3); fees.Cmp(want) != 0 {
+		t.Fatalf("total fees mismatch: have %v, want %v", fees, want)
+	}
+	if _, err := block.TotalFees(receipts[:1]); err == nil {
+		t.Fatal("misaligned receipts accepted")
+	}
+	if _, err := block.TotalFees(nil); err == nil {
+		t.Fatal("missing receipts accepted")
+	}
+	decreasing := Receipts{{CumulativeGasUsed: 42000}, {CumulativeGasUsed: 21000}}
+	if fees, err := block.TotalFees(decreasing); err == nil {
+		t.Fatalf("decreasing cumulative gas accepted, fees %v", fees)
+	}
+}
+
+func TestIsAllContractCalls(t *testing.T) {
+	var (
+		call     = NewTransaction(0, common.Address{1}, big.NewInt(0), 50000, big.NewInt(1), []byte{0x01})
+		transfer = NewTransaction(1, common.Address{2}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		create   = NewContractCreation(2, big.NewInt(0), 50000, big.NewInt(1), []byte{0x60})
+		header   = &Header{Number: big.NewInt(1)}
+	)
+	tests := []struct {
+		txs  []*Transaction
+		want bool
+	}{
+		{nil, false},
+		{[]*Transaction{call, call}, true},
+		{[]*Transaction{call, transfer}, false},
+		{[]*Transaction{call, create}, false},
+	}
+	for i, tt := range tests {
+		block := NewBlock(header, tt.txs, nil, nil, newHasher())
+		if have := block.IsAllContractCalls(); have != tt.want {
+			t.Errorf("test %d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestHeaderPowInput(t *testing.T) {
+	header := &Header{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(1),
+		GasLimit:   5000,
+		Time:       1426516743,
+		MixDigest:  common.HexToHash("0xbd4472abb6659ebe3ee06ee4d7b72a00a9f4d001caca51342001075469aff498"),
+		Nonce:      EncodeNonce(0xa13a5a8c8f2bb1c4),
+	}
+	hash, nonce := header.PowInput()
+	if hash != header.HashNoNonce() {
+		t.Errorf("hash mismatch: have %x, want %x", hash, header.HashNoNonce())
+	}
+	if nonce != header.Nonce.Uint64() {
+		t.Errorf("nonce mismatch: have %x, want %x", nonce, header.Nonce.Uint64())
+	}
+	// The seal fields must not influence the proof-of-work digest.
+	sealed := CopyHeader(header)
+	sealed.Nonce, sealed.MixDigest = EncodeNonce(1), common.Hash{1}
+	if sealed.HashNoNonce() != hash {
+		t.Error("seal fields changed HashNoNonce")
+	}
+	if sealed.Hash() == header.Hash() {
+		t.Error("seal fields did not change Hash")
+	}
+}
+
+func TestReceiptStatusCounts(t *testing.T) {
+	txs := newTestTxs(1, 1, 1)
+	receipts := newTestReceipts(21000, 21000, 21000)
+	receipts[1].Status = ReceiptStatusFailed
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	success, failed, err := block.ReceiptStatusCounts(receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if success != 2 || failed != 1 {
+		t.Fatalf("status counts mismatch: have %d/%d, want 2/1", success, failed)
+	}
+	if _, _, err := block.ReceiptStatusCounts(nil); err == nil {
+		t.Fatal("missing receipts accepted")
+	}
+}
+
+func TestCheckpointEncoding(t *testing.T) {
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(4096), Difficulty: big.NewInt(131072)})
+	td := new(big.Int).Mul(big.NewInt(131072), big.NewInt(4097))
+
+	want := block.Checkpoint(td)
+	if want.Number != 4096 || want.Hash != block.Hash() || want.TD.Cmp(td) != 0 {
+		t.Fatalf("checkpoint mismatch: %+v", want)
+	}
+	enc, err := rlp.EncodeToBytes(want)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var have Checkpoint
+	if err := rlp.DecodeBytes(enc, &have); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("round trip mismatch: have %+v, want %+v", have, want)
+	}
+	if cp := block.Checkpoint(nil); cp.TD != nil || cp.Hash != block.Hash() {
+		t.Fatalf("checkpoint without total difficulty mismatch: %+v", cp)
+	}
+}
+
+func TestDiffHeaders(t *testing.T) {
+	a := &Header{
+		Root:       common.HexToHash("0xaaaa"),
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(1),
+		Extra:      []byte("same"),
+	}
+	if diffs := DiffHeaders(a, CopyHeader(a)); len(diffs) != 0 {
+		t.Fatalf("identical headers reported different: %v", diffs)
+	}
+	b := CopyHeaderFrom(a, func(h *Header) {
+		h.Root = common.HexToHash("0xbbbb")
+		h.Difficulty.SetUint64(131073)
+	})
+	want := []string{
+		"Root: 0x000000000000000000000000000000000000000000000000000000000000aaaa != 0x000000000000000000000000000000000000000000000000000000000000bbbb",
+		"Difficulty: 131072 != 131073",
+	}
+	if have := DiffHeaders(a, b); !reflect.DeepEqual(have, want) {
+		t.Fatalf("diff mismatch:\nhave %q\nwant %q", have, want)
+	}
+}
+
+func TestRefreshReceiptHeader(t *testing.T) {
+	txs := newTestTxs(1)
+	receipts := newTestReceipts(21000)
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	// Change the receipts after the fact, invalidating the header.
+	receipts[0].Logs = []*Log{{Address: common.Address{0xaa}, Topics: []common.Hash{{0xbb}}}}
+	receipts[0].Bloom = CreateBloom(receipts)
+	if block.BloomValid(receipts) || block.ReceiptHashMatches(receipts, newHasher()) {
+		t.Fatal("stale header reported valid")
+	}
+	refreshed := block.RefreshReceiptHeader(receipts, newHasher())
+	if !refreshed.BloomValid(receipts) {
+		t.Error("refreshed bloom invalid")
+	}
+	if !refreshed.ReceiptHashMatches(receipts, newHasher()) {
+		t.Error("refreshed receipt hash mismatch")
+	}
+	if refreshed.Hash() == block.Hash() {
+		t.Error("refreshed block hash unchanged")
+	}
+	if block.BloomValid(receipts) {
+		t.Error("original block modified")
+	}
+}
+
+func TestHeaderHasExtra(t *testing.T) {
+	if (&Header{}).HasExtra() {
+		t.Error("nil extra reported present")
+	}
+	if (&Header{Extra: []byte{}}).HasExtra() {
+		t.Error("empty extra reported present")
+	}
+	if !(&Header{Extra: make([]byte, 32)}).HasExtra() {
+		t.Error("vanity extra reported missing")
+	}
+}
+
+func TestBlockLogs(t *testing.T) {
+	txs := newTestTxs(1, 1, 1)
+	receipts := Receipts{
+		{Logs: []*Log{{Address: common.Address{1}}, {Address: common.Address{2}}}},
+		{},
+		{Logs: []*Log{{Address: common.Address{3}}}},
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	logs := block.Logs(receipts)
+	if len(logs) != 3 {
+		t.Fatalf("log count mismatch: have %d, want 3", len(logs))
+	}
+	for i, log := range logs {
+		if want := (common.Address{byte(i + 1)}); log.Address != want {
+			t.Errorf("log %d: address mismatch: have %x, want %x", i, log.Address, want)
+		}
+	}
+	if logs := block.Logs(nil); len(logs) != 0 {
+		t.Errorf("logs returned without receipts: %v", logs)
+	}
+}
+
+func TestPreferSeal(t *testing.T) {
+	payload := &Header{Difficulty: big.NewInt(131072), Number: big.NewInt(1), GasLimit: 5000}
+	a := CopyHeaderFrom(payload, func(h *Header) { h.Nonce = EncodeNonce(1) })
+	b := CopyHeaderFrom(payload, func(h *Header) { h.Nonce = EncodeNonce(2) })
+
+	want := a
+	if ah, bh := a.Hash(), b.Hash(); bytes.Compare(bh[:], ah[:]) < 0 {
+		want = b
+	}
+	if have := PreferSeal(a, b); have != want {
+		t.Errorf("wrong seal preferred: have %x, want %x", have.Hash(), want.Hash())
+	}
+	if have := PreferSeal(b, a); have != want {
+		t.Errorf("preference depends on argument order: have %x, want %x", have.Hash(), want.Hash())
+	}
+	other := CopyHeaderFrom(a, func(h *Header) { h.GasLimit++ })
+	if have := PreferSeal(a, other); have != nil {
+		t.Errorf("seals of different payloads compared: %x", have.Hash())
+	}
+}
+
+func TestTransactionTypeCounts(t *testing.T) {
+	to := common.Address{1}
+	txs := []*Transaction{
+		NewTransaction(0, to, big.NewInt(0), 21000, big.NewInt(1), nil),
+		NewTx(&AccessListTx{ChainID: big.NewInt(1), Nonce: 1, To: &to, Gas: 21000, GasPrice: big.NewInt(1)}),
+		NewTx(&DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 2, To: &to, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}),
+		NewTx(&DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 3, To: &to, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}),
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, nil, newHasher())
+
+	want := map[uint8]int{LegacyTxType: 1, AccessListTxType: 1, DynamicFeeTxType: 2}
+	if have := block.TransactionTypeCounts(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("type counts mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestBlocksFilterByTimeRange(t *testing.T) {
+	var blocks Blocks
+	for _, ts := range []uint64{90, 100, 150, 200, 210} {
+		blocks = append(blocks, NewBlockWithHeader(&Header{Number: big.NewInt(int64(ts)), Time: ts}))
+	}
+	filtered := blocks.FilterByTimeRange(time.Unix(100, 0), time.Unix(200, 0))
+	if len(filtered) != 3 {
+		t.Fatalf("filtered block count mismatch: have %d, want 3", len(filtered))
+	}
+	for i, want := range []uint64{100, 150, 200} {
+		if have := filtered[i].Time(); have != want {
+			t.Errorf("block %d: time mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if none := blocks.FilterByTimeRange(time.Unix(300, 0), time.Unix(400, 0)); none == nil || len(none) != 0 {
+		t.Errorf("expected empty non-nil result, got %v", none)
+	}
+}
+
+func TestHeaderExtraMaxDecoding(t *testing.T) {
+	header := &Header{Difficulty: big.NewInt(1), Number: big.NewInt(1), Extra: make([]byte, HeaderExtraMax)}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("header at the limit rejected: %v", err)
+	}
+	header.Extra = make([]byte, HeaderExtraMax+1)
+	if enc, err = rlp.EncodeToBytes(header); err != nil {
+		t.Fatal(err)
+	}
+	if err := rlp.DecodeBytes(enc, &dec); !errors.Is(err, ErrHeaderExtraTooLarge) {
+		t.Fatalf("wrong error for oversized extra: %v", err)
+	}
+	// The limit must also apply to headers nested in blocks.
+	if enc, err = rlp.EncodeToBytes(NewBlockWithHeader(header)); err != nil {
+		t.Fatal(err)
+	}
+	var block Block
+	if err := rlp.DecodeBytes(enc, &block); !errors.Is(err, ErrHeaderExtraTooLarge) {
+		t.Fatalf("wrong error for oversized extra in block: %v", err)
+	}
+}
+
+func TestHeaderDensity(t *testing.T) {
+	tests := []struct {
+		header *Header
+		want   float64
+	}{
+		{&Header{GasLimit: 30000000, GasUsed: 15000000}, 0.5},
+		{&Header{GasLimit: 30000000, GasUsed: 30000000}, 1},
+		{&Header{GasLimit: 30000000}, 0},
+		{&Header{}, 0},
+		{nil, 0},
+	}
+	for i, tt := range tests {
+		if have := tt.header.Density(); have != tt.want {
+			t.Errorf("test %d: density mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestHeaderClampTimeTo(t *testing.T) {
+	header := &Header{Time: 100}
+	header.ClampTimeTo(101)
+	if header.Time != 101 {
+		t.Errorf("early timestamp not clamped: have %d, want 101", header.Time)
+	}
+	header.ClampTimeTo(50)
+	if header.Time != 101 {
+		t.Errorf("later timestamp modified: have %d, want 101", header.Time)
+	}
+}
+
+func TestBodiesEncoding(t *testing.T) {
+	block := makeBenchBlock()
+	bodies := []*Body{block.Body(), {}, block.Body()}
+
+	var buf bytes.Buffer
+	if err := EncodeBodies(&buf, bodies); err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	decoded, err := DecodeBodies(&buf, uint64(buf.Len()))
+	if err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if len(decoded) != len(bodies) {
+		t.Fatalf("body count mismatch: have %d, want %d", len(decoded), len(bodies))
+	}
+	for i, body := range decoded {
+		have := NewBlockWithHeader(block.Header()).WithBody(body.Transactions, body.Uncles)
+		want := NewBlockWithHeader(block.Header()).WithBody(bodies[i].Transactions, bodies[i].Uncles)
+		if have.Size() != want.Size() {
+			t.Errorf("body %d: size mismatch: have %v, want %v", i, have.Size(), want.Size())
+		}
+	}
+}
+
+func TestBodiesDecodingLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeBodies(&buf, make([]*Body, maxDecodeBodies+1)); err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if _, err := DecodeBodies(bytes.NewReader(buf.Bytes()), uint64(buf.Len())); err == nil {
+		t.Fatal("oversized body list accepted")
+	}
+	// The count must be checked before the extra entry is decoded.
+	items := make([]interface{}, maxDecodeBodies+1)
+	for i := 0; i < maxDecodeBodies; i++ {
+		items[i] = new(Body)
+	}
+	items[maxDecodeBodies] = rlp.RawValue{0x01}
+	enc, err := rlp.EncodeToBytes(items)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if _, err := DecodeBodies(bytes.NewReader(enc), uint64(len(enc))); err == nil || !strings.Contains(err.Error(), "too many bodies") {
+		t.Fatalf("extra body decoded before count check: %v", err)
+	}
+	// The input limit must be enforced for readers of unknown size.
+	if _, err := DecodeBodies(io.MultiReader(bytes.NewReader(enc)), 64); err == nil {
+		t.Fatal("input beyond limit accepted")
+	}
+}
+
+func TestBlocksCumulativeGasUsed(t *testing.T) {
+	var (
+		blocks Blocks
+		sum    uint64
+	)
+	for i, used := range []uint64{21000, 0, 15000000, 42000} {
+		blocks = append(blocks, NewBlockWithHeader(&Header{Number: big.NewInt(int64(i)), GasUsed: used}))
+		sum += used
+	}
+	totals := blocks.CumulativeGasUsed()
+	if len(totals) != len(blocks) {
+		t.Fatalf("total count mismatch: have %d, want %d", len(totals), len(blocks))
+	}
+	if want := []uint64{21000, 21000, 15021000, 15063000}; !reflect.DeepEqual(totals, want) {
+		t.Fatalf("running totals mismatch: have %v, want %v", totals, want)
+	}
+	if last := totals[len(totals)-1]; last != sum {
+		t.Fatalf("final total mismatch: have %d, want %d", last, sum)
+	}
+}
+
+func TestBlockParentLinkValid(t *testing.T) {
+	tests := []struct {
+		header *Header
+		valid  bool
+	}{
+		{&Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}, true},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), ParentHash: common.Hash{1}}, true},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}, false},
+		{&Header{Difficulty: big.NewInt(1), ParentHash: common.Hash{1}}, false},
+	}
+	for i, tt := range tests {
+		block := &Block{header: tt.header}
+		if have := block.ParentLinkValid(); have != tt.valid {
+			t.Errorf("test %d: parent link validity mismatch: have %v, want %v", i, have, tt.valid)
+		}
+		if err := block.SanityCheck(); (err == nil) != tt.valid {
+			t.Errorf("test %d: sanity check mismatch: %v", i, err)
+		}
+	}
+}
+
+func TestHeaderHashInto(t *testing.T) {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte("stale state"))
+
+	for _, header := range []*Header{makeBenchBlock().Header(), {Difficulty: big.NewInt(1), Number: big.NewInt(0)}} {
+		if have, want := header.HashInto(hasher), header.Hash(); have != want {
+			t.Errorf("hash mismatch: have %x, want %x", have, want)
+		}
+	}
+}
+
+func TestReorgDepth(t *testing.T) {
+	// Build a chain genesis <- 1 <- 2 <- 3 and a fork 1 <- 2' <- 3' <- 4'.
+	headers := make(map[common.Hash]*Header)
+	child := func(parent *Header, extra string) *Header {
+		h := &Header{Number: new(big.Int).Add(parent.Number, common.Big1), ParentHash: parent.Hash(), Extra: []byte(extra)}
+		headers[h.Hash()] = h
+		return h
+	}
+	genesis := &Header{Number: big.NewInt(0)}
+	headers[genesis.Hash()] = genesis
+
+	b1 := child(genesis, "")
+	b3 := child(child(b1, ""), "")
+	f4 := child(child(child(b1, "fork"), "fork"), "fork")
+	parentOf := func(hash common.Hash) *Header { return headers[hash] }
+
+	tests := []struct {
+		oldHead, newHead *Header
+		maxDepth         int
+		depth            int
+		fail             bool
+	}{
+		{b3, f4, 10, 2, false},
+		{f4, b3, 10, 3, false},
+		{b3, b3, 10, 0, false},
+		{b1, f4, 10, 0, false},
+		{b3, f4, 1, 0, true},
+		{b3, &Header{Number: big.NewInt(2), ParentHash: common.Hash{0xff}}, 10, 0, true},
+		// The steps on the new side count against the bound as well.
+		{b1, f4, 2, 0, true},
+		{b3, &Header{ParentHash: b1.Hash()}, 10, 0, true},
+		{b3, &Header{Number: big.NewInt(2), ParentHash: common.Hash{0xee}}, 10, 0, true},
+	}
+	headers[common.Hash{0xee}] = &Header{ParentHash: genesis.Hash()}
+	for i, tt := range tests {
+		depth, err := ReorgDepth(tt.oldHead, tt.newHead, parentOf, tt.maxDepth)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: %v", i, err)
+		}
+		if depth != tt.depth {
+			t.Errorf("test %d: depth mismatch: have %d, want %d", i, depth, tt.depth)
+		}
+	}
+}
+
+func TestTransactionsAboveValue(t *testing.T) {
+	var txs []*Transaction
+	for i, value := range []int64{5, 100, 10, 1000, 11} {
+		txs = append(txs, NewTransaction(uint64(i), common.Address{1}, big.NewInt(value), 21000, big.NewInt(1), nil))
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, nil, newHasher())
+
+	above := block.TransactionsAboveValue(big.NewInt(10))
+	if len(above) != 3 {
+		t.Fatalf("transaction count mismatch: have %d, want 3", len(above))
+	}
+	for i, want := range []int64{100, 1000, 11} {
+		if above[i].Value().Int64() != want {
+			t.Errorf("transaction %d: value mismatch: have %v, want %d", i, above[i].Value(), want)
+		}
+	}
+	if none := block.TransactionsAboveValue(big.NewInt(1000)); none == nil || len(none) != 0 {
+		t.Errorf("expected empty non-nil result, got %v", none)
+	}
+}
+
+func TestSkeletonChecksum(t *testing.T) {
+	var skeleton []*Header
+	for i := int64(1); i <= 4; i++ {
+		skeleton = append(skeleton, &Header{Number: big.NewInt(i * 192)})
+	}
+	sum := SkeletonChecksum(skeleton)
+	if again := SkeletonChecksum(skeleton); again != sum {
+		t.Fatalf("checksum not stable: %x != %x", again, sum)
+	}
+	var concat []byte
+	for _, header := range skeleton {
+		concat = append(concat, header.Hash().Bytes()...)
+	}
+	if want := crypto.Keccak256Hash(concat); sum != want {
+		t.Fatalf("checksum mismatch: have %x, want %x", sum, want)
+	}
+	swapped := []*Header{skeleton[1], skeleton[0], skeleton[2], skeleton[3]}
+	if SkeletonChecksum(swapped) == sum {
+		t.Fatal("checksum not order sensitive")
+	}
+}
+
+func TestHeaderBlockTime(t *testing.T) {
+	parent := &Header{Time: 1426516743}
+
+	delta, err := (&Header{Time: parent.Time + 13}).BlockTime(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta != 13*time.Second {
+		t.Fatalf("block time mismatch: have %v, want 13s", delta)
+	}
+	if _, err := (&Header{Time: parent.Time}).BlockTime(parent); err == nil {
+		t.Fatal("equal timestamp accepted")
+	}
+	if _, err := (&Header{Time: parent.Time - 1}).BlockTime(parent); err == nil {
+		t.Fatal("backwards timestamp accepted")
+	}
+}
+
+func TestDecodeHeaderExact(t *testing.T) {
+	fields := []interface{}{
+		common.Hash{1}, EmptyUncleHash, common.Address{2}, common.Hash{3}, EmptyRootHash, EmptyRootHash, Bloom{},
+		big.NewInt(131072), big.NewInt(1), uint64(5000), uint64(21000), uint64(1426516743), []byte("extra"),
+		common.Hash{4}, EncodeNonce(5),
+	}
+	decode := func(fields []interface{}) (*Header, error) {
+		enc, err := rlp.EncodeToBytes(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return DecodeHeaderExact(rlp.NewStream(bytes.NewReader(enc), 0))
+	}
+	for _, valid := range [][]interface{}{fields, append(fields[:15:15], big.NewInt(params.InitialBaseFee))} {
+		header, err := decode(valid)
+		if err != nil {
+			t.Fatalf("valid header with %d fields rejected: %v", len(valid), err)
+		}
+		var want Header
+		enc, _ := rlp.EncodeToBytes(valid)
+		if err := rlp.DecodeBytes(enc, &want); err != nil {
+			t.Fatal(err)
+		}
+		if header.Hash() != want.Hash() {
+			t.Fatalf("decoded header mismatch: have %x, want %x", header.Hash(), want.Hash())
+		}
+	}
+	if _, err := decode(fields[:14]); err == nil {
+		t.Error("header with missing field accepted")
+	}
+	if _, err := decode(append(fields[:15:15], big.NewInt(1), uint64(1))); err == nil {
+		t.Error("header with extra field accepted")
+	}
+}
+
+func TestBlockWouldFit(t *testing.T) {
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(1), GasLimit: 100000, GasUsed: 79000})
+	if !block.WouldFit(21000) {
+		t.Error("exactly fitting transaction rejected")
+	}
+	if block.WouldFit(21001) {
+		t.Error("overflowing transaction accepted")
+	}
+	if block.WouldFit(math.MaxUint64) {
+		t.Error("uint64 overflow not handled")
+	}
+	if NewBlockWithHeader(&Header{Number: big.NewInt(1)}).WouldFit(1) {
+		t.Error("transaction fits block without gas limit")
+	}
+}
+
+func TestBlocksTotalTransactions(t *testing.T) {
+	tx := NewTransaction(0, common.Address{1}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	var blocks Blocks
+	for i, count := range []int{0, 3, 1, 5} {
+		txs := make([]*Transaction, count)
+		for j := range txs {
+			txs[j] = tx
+		}
+		blocks = append(blocks, NewBlock(&Header{Number: big.NewInt(int64(i))}, txs, nil, nil, newHasher()))
+	}
+	if total := blocks.TotalTransactions(); total != 9 {
+		t.Fatalf("total transaction count mismatch: have %d, want 9", total)
+	}
+}
+
+func TestHeaderLooksLikeTestnet(t *testing.T) {
+	mainnet := new(big.Int)
+	mainnet.SetString("5ad3c2c71bbff8", 16)
+
+	tests := []struct {
+		header *Header
+		want   bool
+	}{
+		{&Header{Difficulty: mainnet, Extra: []byte("Geth/v1.10.17/linux")}, false},
+		{&Header{Difficulty: big.NewInt(17179869184)}, false},
+		{&Header{Difficulty: big.NewInt(2), Extra: make([]byte, 32+65)}, true},
+		{&Header{Difficulty: big.NewInt(131072)}, true},
+		{&Header{Difficulty: mainnet, Extra: make([]byte, 32+65)}, true},
+		{&Header{Difficulty: new(big.Int), Extra: []byte("Geth/v1.10.23/linux")}, false}, // post-merge mainnet
+		{&Header{Difficulty: new(big.Int), Extra: make([]byte, 32+65)}, true},
+	}
+	for i, tt := range tests {
+		if have := tt.header.LooksLikeTestnet(); have != tt.want {
+			t.Errorf("test %d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestBlockPartitionByStatus(t *testing.T) {
+	txs := newTestTxs(1, 1, 1)
+	receipts := newTestReceipts(21000, 21000, 21000)
+	receipts[1].Status = ReceiptStatusFailed
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	succeeded, reverted, err := block.PartitionByStatus(receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(succeeded) != 2 || succeeded[0] != txs[0] || succeeded[1] != txs[2] {
+		t.Errorf("succeeded transactions mismatch: %v", succeeded)
+	}
+	if len(reverted) != 1 || reverted[0] != txs[1] {
+		t.Errorf("reverted transactions mismatch: %v", reverted)
+	}
+	if _, _, err := block.PartitionByStatus(receipts[:2]); err == nil {
+		t.Error("misaligned receipts accepted")
+	}
+	// Pre-Byzantium receipts carry no status and must not count as reverted.
+	legacy := Receipts{
+		{PostState: common.Hash{1}.Bytes(), CumulativeGasUsed: 21000},
+		{PostState: common.Hash{2}.Bytes(), CumulativeGasUsed: 42000},
+		{Status: ReceiptStatusFailed, CumulativeGasUsed: 63000},
+	}
+	succeeded, reverted, err = block.PartitionByStatus(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(succeeded) != 0 || len(reverted) != 1 || reverted[0] != txs[2] {
+		t.Errorf("pre-Byzantium receipts partitioned: succeeded %v, reverted %v", succeeded, reverted)
+	}
+	success, failed, _ := block.ReceiptStatusCounts(legacy)
+	if success != len(succeeded) || failed != len(reverted) {
+		t.Errorf("status counts disagree with partition: %d/%d vs %d/%d", success, failed, len(succeeded), len(reverted))
+	}
+}
+
+// Tests that the uncle hash is the flat keccak256 of the RLP uncle list rather
+// than a trie root like the transaction and receipt hashes.
+func TestUncleHashAlgorithm(t *testing.T) {
+	uncles := []*Header{
+		{Difficulty: big.NewInt(131072), Number: big.NewInt(1), Extra: []byte("uncle 1")},
+		{Difficulty: big.NewInt(131072), Number: big.NewInt(2), Extra: []byte("uncle 2")},
+	}
+	enc, err := rlp.EncodeToBytes(uncles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crypto.Keccak256Hash(enc)
+	if have := CalcUncleHash(uncles); have != want {
+		t.Fatalf("uncle hash mismatch: have %x, want %x", have, want)
+	}
+	if block := NewBlock(&Header{Number: big.NewInt(3)}, nil, uncles, nil, newHasher()); block.UncleHash() != want {
+		t.Fatalf("block uncle hash mismatch: have %x, want %x", block.UncleHash(), want)
+	}
+}
+
+func TestBlockReceivedLag(t *testing.T) {
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(1), Time: 1426516743})
+	if lag := block.ReceivedLag(); lag != 0 {
+		t.Fatalf("lag without receive time: have %v, want 0", lag)
+	}
+	hash := block.Hash()
+
+	block.ReceivedAt = time.Unix(1426516743, 0).Add(1500 * time.Millisecond)
+	if lag := block.ReceivedLag(); lag != 1500*time.Millisecond {
+		t.Fatalf("lag mismatch: have %v, want 1.5s", lag)
+	}
+	if block.Header().Hash() != hash {
+		t.Fatal("receive time affected block hash")
+	}
+}
+
+func TestHeaderNextGasLimit(t *testing.T) {
+	const divisor = params.GasLimitBoundDivisor
+	tests := []struct {
+		limit, target, want uint64
+	}{
+		{8000000, 30000000, 8000000 + 8000000/divisor - 1},
+		{8000000, 8000100, 8000100},
+		{30000000, 8000000, 30000000 - 30000000/divisor + 1},
+		{30000000, 29999900, 29999900},
+		{15000000, 15000000, 15000000},
+		{1000, 2000, 1000},
+	}
+	for i, tt := range tests {
+		if have := (&Header{GasLimit: tt.limit}).NextGasLimit(tt.target, divisor); have != tt.want {
+			t.Errorf("test %d: gas limit mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+	if have := (&Header{GasLimit: 8000000}).NextGasLimit(30000000, 0); have != 8000000 {
+		t.Errorf("zero divisor: gas limit mismatch: have %d, want 8000000", have)
+	}
+}
+
+func TestBlockInvolvesAddress(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		signer   = HomesteadSigner{}
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		emitter  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		watched  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		stranger = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	)
+	tx, err := SignTx(NewTransaction(0, emitter, big.NewInt(0), 50000, big.NewInt(1), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts := Receipts{{
+		Status: ReceiptStatusSuccessful,
+		Logs:   []*Log{{Address: emitter, Topics: []common.Hash{{0x01}, common.BytesToHash(watched.Bytes())}}},
+	}}
+	receipts[0].Bloom = CreateBloom(receipts)
+	block := NewBlock(&Header{Number: big.NewInt(1), Coinbase: common.Address{0xc0}}, []*Transaction{tx}, nil, receipts, newHasher())
+
+	for _, addr := range []common.Address{sender, emitter, watched, {0xc0}} {
+		if !block.InvolvesAddress(signer, receipts, addr) {
+			t.Errorf("address %x not found", addr)
+		}
+	}
+	if block.InvolvesAddress(signer, receipts, stranger) {
+		t.Errorf("unrelated address %x found", stranger)
+	}
+}
+
+func TestBlockGasUsedConsistent(t *testing.T) {
+	txs := newTestTxs(1, 1)
+	receipts := newTestReceipts(21000, 35000)
+
+	block := NewBlock(&Header{Number: big.NewInt(1), GasUsed: 56000}, txs, nil, receipts, newHasher())
+	if err := block.GasUsedConsistent(receipts); err != nil {
+		t.Fatalf("consistent block rejected: %v", err)
+	}
+	block = NewBlock(&Header{Number: big.NewInt(1), GasUsed: 42000}, txs, nil, receipts, newHasher())
+	if err := block.GasUsedConsistent(receipts); err == nil {
+		t.Fatal("wrong header gas used accepted")
+	} else if !strings.Contains(err.Error(), "42000") || !strings.Contains(err.Error(), "56000") {
+		t.Fatalf("error does not report both values: %v", err)
+	}
+	decreasing := Receipts{{CumulativeGasUsed: 42000}, {CumulativeGasUsed: 21000}}
+	if err := block.GasUsedConsistent(decreasing); err == nil {
+		t.Fatal("decreasing cumulative gas used accepted")
+	}
+}
+
+func TestTransactionsRLP(t *testing.T) {
+	block := makeBenchBlock()
+
+	enc, err := block.TransactionsRLP()
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	txs, err := DecodeTransactionsRLP(enc)
+	if err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if len(txs) != len(block.Transactions()) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(block.Transactions()))
+	}
+	if hash := DeriveSha(txs, newHasher()); hash != block.TxHash() {
+		t.Fatalf("transaction root mismatch: have %x, want %x", hash, block.TxHash())
+	}
+	if _, err := DecodeTransactionsRLP(enc[:len(enc)-1]); err == nil {
+		t.Fatal("truncated list accepted")
+	}
+}
+
+func TestBlockSameChainPosition(t *testing.T) {
+	var (
+		header  = &Header{Number: big.NewInt(10), ParentHash: common.Hash{1}}
+		block   = NewBlockWithHeader(header)
+		sibling = NewBlockWithHeader(CopyHeaderFrom(header, func(h *Header) { h.Extra = []byte("sibling") }))
+		other   = NewBlockWithHeader(CopyHeaderFrom(header, func(h *Header) { h.Number.SetUint64(11) }))
+	)
+	if !block.SameChainPosition(sibling) {
+		t.Error("sibling not in the same position")
+	}
+	if block.Hash() == sibling.Hash() {
+		t.Error("sibling has the same hash")
+	}
+	if !block.SameChainPosition(NewBlockWithHeader(header)) {
+		t.Error("duplicate not in the same position")
+	}
+	if block.SameChainPosition(other) {
+		t.Error("different number in the same position")
+	}
+	unnumbered := &Block{header: &Header{ParentHash: common.Hash{1}}}
+	if block.SameChainPosition(unnumbered) || unnumbered.SameChainPosition(unnumbered) {
+		t.Error("block without number in the same position")
+	}
+}
+
+func TestBlockRangeTransactions(t *testing.T) {
+	block := makeBenchBlock()
+
+	var visited []int
+	block.RangeTransactions(func(i int, tx *Transaction) bool {
+		if tx != block.Transactions()[i] {
+			t.Errorf("transaction %d mismatch", i)
+		}
+		visited = append(visited, i)
+		return i < 2
+	})
+	if !reflect.DeepEqual(visited, []int{0, 1, 2}) {
+		t.Fatalf("iteration did not stop early: visited %v", visited)
+	}
+}
+
+func TestHeaderStorageRLP(t *testing.T) {
+	for _, want := range []*Header{
+		makeBenchBlock().Header(),
+		{Difficulty: big.NewInt(1), Number: big.NewInt(1), Extra: []byte{}, Nonce: EncodeNonce(42), BaseFee: big.NewInt(params.InitialBaseFee)},
+	} {
+		enc, err := want.StorageRLP()
+		if err != nil {
+			t.Fatal("encode error: ", err)
+		}
+		if have := crypto.Keccak256Hash(enc); have != want.Hash() {
+			t.Fatalf("storage encoding does not hash to header hash: %x != %x", have, want.Hash())
+		}
+		have, err := HeaderFromStorageRLP(enc)
+		if err != nil {
+			t.Fatal("decode error: ", err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Fatalf("round trip mismatch:\nhave %+v\nwant %+v", have, want)
+		}
+		if have.Hash() != want.Hash() {
+			t.Fatalf("hash mismatch: have %x, want %x", have.Hash(), want.Hash())
+		}
+	}
+}
+
+func TestHeaderVerifySeal(t *testing.T) {
+	header := &Header{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(1),
+		MixDigest:  common.HexToHash("0xdead"),
+		Nonce:      EncodeNonce(0xcafe),
+	}
+	errStub := errors.New("stub")
+	err := header.VerifySeal(func(hash common.Hash, nonce uint64, mix common.Hash, difficulty *big.Int) error {
+		if hash != header.HashNoNonce() {
+			t.Errorf("hash mismatch: have %x, want %x", hash, header.HashNoNonce())
+		}
+		if nonce != 0xcafe {
+			t.Errorf("nonce mismatch: have %x, want %x", nonce, 0xcafe)
+		}
+		if mix != header.MixDigest {
+			t.Errorf("mix digest mismatch: have %x, want %x", mix, header.MixDigest)
+		}
+		if difficulty.Cmp(header.Difficulty) != 0 {
+			t.Errorf("difficulty mismatch: have %v, want %v", difficulty, header.Difficulty)
+		}
+		return errStub
+	})
+	if err != errStub {
+		t.Fatalf("verifier error not propagated: have %v, want %v", err, errStub)
+	}
+}
+
+func TestBlockSortNumberThenHash(t *testing.T) {
+	var (
+		a = NewBlockWithHeader(&Header{Number: big.NewInt(1), Extra: []byte("a")})
+		b = NewBlockWithHeader(&Header{Number: big.NewInt(1), Extra: []byte("b")})
+		c = NewBlockWithHeader(&Header{Number: big.NewInt(0)})
+	)
+	lo, hi := a, b
+	if ah, bh := a.Hash(), b.Hash(); bytes.Compare(bh[:], ah[:]) < 0 {
+		lo, hi = b, a
+	}
+	for _, blocks := range []Blocks{{a, b, c}, {b, a, c}, {c, b, a}} {
+		BlockBy(NumberThenHash).Sort(blocks)
+		if blocks[0] != c || blocks[1] != lo || blocks[2] != hi {
+			t.Fatalf("unexpected order: %x %x %x", blocks[0].Hash(), blocks[1].Hash(), blocks[2].Hash())
+		}
+	}
+	unnumbered := &Block{header: &Header{}}
+	blocks := Blocks{a, c, unnumbered}
+	BlockBy(NumberThenHash).Sort(blocks)
+	if blocks[0] != unnumbered || blocks[1] != c || blocks[2] != a {
+		t.Fatal("block without number not ordered first")
+	}
+}
+
+func TestBlockTxSizeHistogram(t *testing.T) {
+	var (
+		to  = common.HexToAddress("0x01")
+		txs = Transactions{
+			NewTransaction(0, to, big.NewInt(0), 21000, big.NewInt(1), nil),
+			NewTransaction(1, to, big.NewInt(0), 21000, big.NewInt(1), make([]byte, 100)),
+			NewTransaction(2, to, big.NewInt(0), 21000, big.NewInt(1), make([]byte, 1000)),
+			NewTransaction(3, to, big.NewInt(0), 21000, big.NewInt(1), make([]byte, 1000)),
+		}
+		block = NewBlockWithHeader(&Header{Number: big.NewInt(1)}).WithBody(txs, nil)
+	)
+	small := int(txs[0].Size())
+	tests := []struct {
+		buckets []int
+		want    []int
+	}{
+		{[]int{0}, []int{4}},
+		{[]int{0, 100, 1000}, []int{1, 1, 2}},
+		{[]int{small, small + 1}, []int{1, 3}},
+		{[]int{small + 1, 2000}, []int{3, 0}},
+		{nil, []int{}},
+	}
+	for i, tt := range tests {
+		if have := block.TxSizeHistogram(tt.buckets); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: histogram mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestBlockUncleNumbersValid(t *testing.T) {
+	withUncles := func(number int64, uncles ...*big.Int) *Block {
+		headers := make([]*Header, len(uncles))
+		for i, n := range uncles {
+			headers[i] = &Header{Number: n}
+		}
+		return NewBlockWithHeader(&Header{Number: big.NewInt(number)}).WithBody(nil, headers)
+	}
+	tests := []struct {
+		block *Block
+		valid bool
+	}{
+		{withUncles(10), true},
+		{withUncles(10, big.NewInt(9), big.NewInt(3)), true},
+		{withUncles(3, big.NewInt(0)), true},
+		{withUncles(10, big.NewInt(2)), false},  // too old
+		{withUncles(10, big.NewInt(10)), false}, // same height
+		{withUncles(10, big.NewInt(11)), false}, // future
+		{withUncles(0, big.NewInt(0)), false},
+		{withUncles(10, big.NewInt(9), nil), false},
+	}
+	for i, tt := range tests {
+		if err := tt.block.UncleNumbersValid(7); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestBlockRLPRoundTrip(t *testing.T) {
+	var (
+		to  = common.HexToAddress("0x01")
+		txs = []*Transaction{
+			NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil),
+			NewTransaction(1, to, big.NewInt(2), 21000, big.NewInt(1), []byte{0x01}),
+		}
+		uncles = []*Header{{Number: big.NewInt(1), Difficulty: big.NewInt(131072), Extra: []byte("uncle")}}
+		block  = NewBlock(&Header{Number: big.NewInt(2), Difficulty: big.NewInt(131072)}, txs, uncles, nil, newHasher())
+	)
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var dec Block
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if dec.Hash() != block.Hash() {
+		t.Errorf("hash mismatch: have %x, want %x", dec.Hash(), block.Hash())
+	}
+	if len(dec.Transactions()) != 2 {
+		t.Errorf("transaction count mismatch: have %d, want 2", len(dec.Transactions()))
+	}
+	for i, tx := range dec.Transactions() {
+		if tx.Hash() != txs[i].Hash() {
+			t.Errorf("transaction %d hash mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+	if len(dec.Uncles()) != 1 || dec.Uncles()[0].Hash() != uncles[0].Hash() {
+		t.Errorf("uncle mismatch: have %v, want %v", dec.Uncles(), uncles)
+	}
+	if dec.Size() != block.Size() {
+		t.Errorf("size mismatch: have %v, want %v", dec.Size(), block.Size())
+	}
+}
+
+func TestBlockTotalMinerReward(t *testing.T) {
+	txs := []*Transaction{
+		NewTx(&DynamicFeeTx{Nonce: 0, To: &common.Address{1}, Gas: 50000, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(20)}),
+		NewTx(&DynamicFeeTx{Nonce: 1, To: &common.Address{2}, Gas: 50000, GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(12)}),
+	}
+	receipts := newTestReceipts(21000, 30000)
+	var (
+		header = &Header{Number: big.NewInt(2), BaseFee: big.NewInt(10)}
+		uncles = []*Header{{Number: big.NewInt(1)}}
+		block  = NewBlock(header, txs, uncles, receipts, newHasher())
+		base   = big.NewInt(2e18)
+	)
+	reward, err := block.TotalMinerReward(base, receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second transaction's tip is capped at feeCap-baseFee = 2.
+	want := big.NewInt(2e18 + 2e18/32 + 21000*2 + 30000*2)
+	if reward.Cmp(want) != 0 {
+		t.Fatalf("reward mismatch: have %v, want %v", reward, want)
+	}
+	if base.Cmp(big.NewInt(2e18)) != 0 {
+		t.Fatalf("base reward modified: %v", base)
+	}
+	if _, err := block.TotalMinerReward(base, receipts[:1]); err == nil {
+		t.Fatal("misaligned receipts accepted")
+	}
+	decreasing := Receipts{{CumulativeGasUsed: 42000}, {CumulativeGasUsed: 21000}}
+	if reward, err := block.TotalMinerReward(base, decreasing); err == nil {
+		t.Fatalf("decreasing cumulative gas accepted, reward %v", reward)
+	}
+}
+
+func TestBlockBodyFingerprint(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x01")
+		txs    = []*Transaction{NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)}
+		uncles = []*Header{{Number: big.NewInt(1), Difficulty: big.NewInt(1)}}
+		a      = NewBlock(&Header{Number: big.NewInt(2)}, txs, uncles, nil, newHasher())
+		b      = NewBlock(&Header{Number: big.NewInt(3), Extra: []byte("other")}, txs, uncles, nil, newHasher())
+	)
+	if a.Hash() == b.Hash() {
+		t.Fatal("test blocks share a hash")
+	}
+	if a.BodyFingerprint() != b.BodyFingerprint() {
+		t.Fatalf("identical bodies have different fingerprints: %x != %x", a.BodyFingerprint(), b.BodyFingerprint())
+	}
+	changed := []*Transaction{NewTransaction(0, to, big.NewInt(2), 21000, big.NewInt(1), nil)}
+	c := NewBlock(&Header{Number: big.NewInt(2)}, changed, uncles, nil, newHasher())
+	if a.BodyFingerprint() == c.BodyFingerprint() {
+		t.Fatal("different bodies share a fingerprint")
+	}
+}
+
+func TestHeaderBinaryExtraRoundTrip(t *testing.T) {
+	extra := make([]byte, 32)
+	for i := range extra {
+		extra[i] = 0xff - byte(i) // not valid UTF-8
+	}
+	header := &Header{Difficulty: big.NewInt(1), Number: big.NewInt(1), Extra: extra}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !bytes.Equal(dec.Extra, extra) {
+		t.Fatalf("extra mismatch: have %x, want %x", dec.Extra, extra)
+	}
+	if dec.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch: have %x, want %x", dec.Hash(), header.Hash())
+	}
+}
+
+func TestHeaderCopy(t *testing.T) {
+	orig := &Header{
+		ParentHash: common.Hash{1},
+		Coinbase:   common.Address{2},
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(100),
+		GasLimit:   8000000,
+		GasUsed:    21000,
+		Time:       1000,
+		Extra:      []byte("extra"),
+		MixDigest:  common.Hash{3},
+		Nonce:      EncodeNonce(4),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	want := orig.Hash()
+
+	cpy := orig.Copy()
+	if cpy.Hash() != want {
+		t.Fatalf("copy hash mismatch: have %x, want %x", cpy.Hash(), want)
+	}
+	cpy.ParentHash[0] = 0xff
+	cpy.UncleHash[0] = 0xff
+	cpy.Coinbase[0] = 0xff
+	cpy.Root[0] = 0xff
+	cpy.TxHash[0] = 0xff
+	cpy.ReceiptHash[0] = 0xff
+	cpy.Bloom[0] = 0xff
+	cpy.Difficulty.SetUint64(1)
+	cpy.Number.SetUint64(1)
+	cpy.GasLimit++
+	cpy.GasUsed++
+	cpy.Time++
+	cpy.Extra[0] = 'E'
+	cpy.MixDigest[0] = 0xff
+	cpy.Nonce[0] = 0xff
+	cpy.BaseFee.SetUint64(1)
+
+	if orig.Hash() != want {
+		t.Fatalf("original modified through copy: %v", DiffHeaders(orig, cpy))
+	}
+	if diffs := DiffHeaders(orig, cpy); len(diffs) != 16 {
+		t.Fatalf("unexpected number of differing fields: have %d, want 16: %v", len(diffs), diffs)
+	}
+}
+
+func TestHeaderClampDifficulty(t *testing.T) {
+	max := big.NewInt(1000)
+	tests := []struct {
+		difficulty, max, want *big.Int
+	}{
+		{big.NewInt(5000), max, max},
+		{big.NewInt(1000), max, max},
+		{big.NewInt(10), max, big.NewInt(10)},
+		{big.NewInt(5000), nil, big.NewInt(5000)},
+		{nil, max, nil},
+	}
+	for i, tt := range tests {
+		h := &Header{Difficulty: tt.difficulty}
+		h.ClampDifficulty(tt.max)
+		if (h.Difficulty == nil) != (tt.want == nil) || (h.Difficulty != nil && h.Difficulty.Cmp(tt.want) != 0) {
+			t.Errorf("test %d: difficulty mismatch: have %v, want %v", i, h.Difficulty, tt.want)
+		}
+	}
+	h := &Header{Difficulty: big.NewInt(5000)}
+	h.ClampDifficulty(max)
+	h.Difficulty.SetUint64(1)
+	if max.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("cap aliased by clamped header: %v", max)
+	}
+}
+
+func TestBlockWithSeal(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x01")
+		txs    = []*Transaction{NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)}
+		uncles = []*Header{{Number: big.NewInt(1), Difficulty: big.NewInt(1)}}
+		block  = NewBlock(&Header{Number: big.NewInt(2), Nonce: EncodeNonce(1)}, txs, uncles, nil, newHasher())
+		hash   = block.Hash()
+	)
+	sealed := block.Header()
+	sealed.Nonce = EncodeNonce(2)
+	sealed.MixDigest = common.Hash{0xff}
+
+	result := block.WithSeal(sealed)
+	if block.Nonce() != 1 || block.MixDigest() != (common.Hash{}) || block.Hash() != hash {
+		t.Fatalf("original block modified: nonce %d, mix %x", block.Nonce(), block.MixDigest())
+	}
+	if result.Nonce() != 2 || result.MixDigest() != sealed.MixDigest {
+		t.Fatalf("seal not installed: nonce %d, mix %x", result.Nonce(), result.MixDigest())
+	}
+	sealed.Nonce = EncodeNonce(3)
+	if result.Nonce() != 2 {
+		t.Fatalf("sealed block aliases the given header: nonce %d", result.Nonce())
+	}
+	if len(result.Transactions()) != 1 || result.Transactions()[0] != txs[0] || len(result.Uncles()) != 1 {
+		t.Fatal("body not carried over to sealed block")
+	}
+}
+
+func TestBlockBloomCoversAllLogs(t *testing.T) {
+	var (
+		topic    = common.HexToHash("0x1234")
+		receipts = Receipts{
+			{Logs: []*Log{{Address: common.HexToAddress("0x01"), Topics: []common.Hash{topic}}}},
+			{Logs: []*Log{{Address: common.HexToAddress("0x02")}}},
+		}
+		bloom = CreateBloom(receipts)
+	)
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(1), Bloom: bloom})
+	if !block.BloomCoversAllLogs(receipts) {
+		t.Fatal("derived bloom rejected")
+	}
+	// Set extra bits: still covers all logs, but no longer valid.
+	extra := bloom
+	extra.Add([]byte("unrelated"))
+	if block := NewBlockWithHeader(&Header{Number: big.NewInt(1), Bloom: extra}); !block.BloomCoversAllLogs(receipts) || block.BloomValid(receipts) {
+		t.Fatal("superset bloom misjudged")
+	}
+	// Clear the bits of the topic.
+	var topicBits Bloom
+	topicBits.Add(topic.Bytes())
+	tampered := bloom
+	for i := range tampered {
+		tampered[i] &^= topicBits[i]
+	}
+	if NewBlockWithHeader(&Header{Number: big.NewInt(1), Bloom: tampered}).BloomCoversAllLogs(receipts) {
+		t.Fatal("bloom missing a topic accepted")
+	}
+}
+
+func TestHeaderDifficultyRatio(t *testing.T) {
+	withDifficulty := func(d *big.Int) *Header { return &Header{Difficulty: d} }
+	tests := []struct {
+		child, parent *Header
+		want          float64
+	}{
+		{withDifficulty(big.NewInt(1100)), withDifficulty(big.NewInt(1000)), 1.1},
+		{withDifficulty(big.NewInt(900)), withDifficulty(big.NewInt(1000)), 0.9},
+		{withDifficulty(big.NewInt(1000)), withDifficulty(big.NewInt(1000)), 1},
+		{withDifficulty(big.NewInt(1000)), withDifficulty(big.NewInt(0)), 0},
+		{withDifficulty(big.NewInt(1000)), withDifficulty(nil), 0},
+		{withDifficulty(big.NewInt(1000)), nil, 0},
+	}
+	for i, tt := range tests {
+		if have := tt.child.DifficultyRatio(tt.parent); have != tt.want {
+			t.Errorf("test %d: ratio mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestBlockRebuildHeaderRoots(t *testing.T) {
+	var (
+		to       = common.HexToAddress("0x01")
+		txs      = []*Transaction{NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)}
+		uncles   = []*Header{{Number: big.NewInt(1), Difficulty: big.NewInt(1)}}
+		receipts = Receipts{{CumulativeGasUsed: 21000, Logs: []*Log{{Address: to}}}}
+		header   = &Header{Number: big.NewInt(2)}
+		want     = NewBlock(header, txs, uncles, receipts, newHasher())
+	)
+	// Assemble the same block piecemeal, leaving all roots unset.
+	block := NewBlockWithHeader(header).WithBody(txs, uncles)
+	if block.Hash() == want.Hash() {
+		t.Fatal("piecemeal block already has the final roots")
+	}
+	stale := block.Hash()
+
+	rebuilt := block.RebuildHeaderRoots(receipts, newHasher())
+	if rebuilt.Hash() != want.Hash() {
+		t.Fatalf("rebuilt block mismatch: %v", DiffHeaders(rebuilt.Header(), want.Header()))
+	}
+	if block.Hash() != stale || block.TxHash() != (common.Hash{}) {
+		t.Fatal("original block modified")
+	}
+	// Without receipts, the receipt root and bloom must be left alone.
+	partial := want.WithBody(txs, nil).RebuildHeaderRoots(nil, newHasher())
+	if partial.UncleHash() != EmptyUncleHash || partial.ReceiptHash() != want.ReceiptHash() || partial.Bloom() != want.Bloom() {
+		t.Fatalf("unexpected partial rebuild: %v", DiffHeaders(partial.Header(), want.Header()))
+	}
+}
+
+func TestBlockHighestGasPriceTx(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	txs := []*Transaction{
+		NewTransaction(0, to, big.NewInt(0), 21000, big.NewInt(5), nil),
+		NewTransaction(1, to, big.NewInt(0), 21000, big.NewInt(9), nil),
+		NewTransaction(2, to, big.NewInt(0), 21000, big.NewInt(1), nil),
+		NewTransaction(3, to, big.NewInt(0), 21000, big.NewInt(9), nil),
+	}
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(1)}).WithBody(txs, nil)
+	if have := block.HighestGasPriceTx(); have != txs[1] {
+		t.Fatalf("wrong transaction: have nonce %d, want 1", have.Nonce())
+	}
+	if have := NewBlockWithHeader(&Header{Number: big.NewInt(1)}).HighestGasPriceTx(); have != nil {
+		t.Fatalf("empty block returned transaction %x", have.Hash())
+	}
+}
+
+func TestBlockBodyReassembly(t *testing.T) {
+	block := makeBenchBlock()
+	header, body := block.Header(), block.Body()
+
+	rebuilt := NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+	if rebuilt.Hash() != block.Hash() {
+		t.Fatalf("hash mismatch: have %x, want %x", rebuilt.Hash(), block.Hash())
+	}
+	if len(rebuilt.Transactions()) != len(block.Transactions()) || len(rebuilt.Uncles()) != len(block.Uncles()) {
+		t.Fatal("body contents mismatch")
+	}
+	if rebuilt.Size() != block.Size() {
+		t.Fatalf("size mismatch: have %v, want %v", rebuilt.Size(), block.Size())
+	}
+}
+
+func TestBlockStateChanged(t *testing.T) {
+	parent := &Header{Number: big.NewInt(1), Root: common.Hash{1}}
+	if NewBlockWithHeader(&Header{Number: big.NewInt(2), Root: common.Hash{1}}).StateChanged(parent) {
+		t.Error("unchanged root reported as changed")
+	}
+	if !NewBlockWithHeader(&Header{Number: big.NewInt(2), Root: common.Hash{2}}).StateChanged(parent) {
+		t.Error("changed root reported as unchanged")
+	}
+}
+
+func TestBlockTransactionsTo(t *testing.T) {
+	var (
+		alice = common.HexToAddress("0xa11ce")
+		bob   = common.HexToAddress("0xb0b")
+		txs   = []*Transaction{
+			NewTransaction(0, alice, big.NewInt(1), 21000, big.NewInt(1), nil),
+			NewTransaction(1, bob, big.NewInt(1), 21000, big.NewInt(1), nil),
+			NewContractCreation(2, big.NewInt(0), 50000, big.NewInt(1), []byte{0x60}),
+			NewTransaction(3, alice, big.NewInt(1), 21000, big.NewInt(1), nil),
+		}
+		block = NewBlockWithHeader(&Header{Number: big.NewInt(1)}).WithBody(txs, nil)
+	)
+	if have := block.TransactionsTo(alice); len(have) != 2 || have[0] != txs[0] || have[1] != txs[3] {
+		t.Errorf("wrong transactions to alice: %v", have)
+	}
+	if have := block.TransactionsTo(bob); len(have) != 1 || have[0] != txs[1] {
+		t.Errorf("wrong transactions to bob: %v", have)
+	}
+	if have := block.TransactionsTo(common.Address{}); have == nil || len(have) != 0 {
+		t.Errorf("contract creation matched zero address: %v", have)
+	}
+}
+
+func TestNewBlockWithHeaderCopies(t *testing.T) {
+	header := &Header{Number: big.NewInt(1), Difficulty: big.NewInt(100), Extra: []byte("a")}
+	block := NewBlockWithHeader(header)
+	hash := block.Header().Hash()
+
+	header.Number.SetUint64(2)
+	header.Difficulty.SetUint64(200)
+	header.Extra[0] = 'b'
+	header.Time = 1
+
+	if block.NumberU64() != 1 {
+		t.Fatalf("block number changed through caller's header: %d", block.NumberU64())
+	}
+	if have := block.Header().Hash(); have != hash {
+		t.Fatalf("block header changed through caller's header: %v", DiffHeaders(block.Header(), header))
+	}
+}
+
+func TestBlockDedupKey(t *testing.T) {
+	var (
+		a = NewBlockWithHeader(&Header{Number: big.NewInt(7), Extra: []byte("a")})
+		b = NewBlockWithHeader(&Header{Number: big.NewInt(7), Extra: []byte("b")})
+	)
+	if a.DedupKey() == b.DedupKey() {
+		t.Fatal("sibling blocks share a dedup key")
+	}
+	key := a.DedupKey()
+	if key != NewBlockWithHeader(a.Header()).DedupKey() {
+		t.Fatal("identical blocks have different dedup keys")
+	}
+	hash := a.Hash()
+	if !bytes.Equal(key[:8], []byte{0, 0, 0, 0, 0, 0, 0, 7}) || !bytes.Equal(key[8:], hash[:]) {
+		t.Fatalf("unexpected key layout: %x", key)
+	}
+}
+
+func TestHeaderExtraHasPrefix(t *testing.T) {
+	tests := []struct {
+		extra, prefix []byte
+		want          bool
+	}{
+		{[]byte("magic-vanity"), []byte("magic"), true},
+		{[]byte("magic"), []byte("magic"), true},
+		{[]byte("vanity"), []byte("magic"), false},
+		{[]byte("mag"), []byte("magic"), false},
+		{nil, []byte("magic"), false},
+		{[]byte("magic"), nil, true},
+	}
+	for i, tt := range tests {
+		if have := (&Header{Extra: tt.extra}).ExtraHasPrefix(tt.prefix); have != tt.want {
+			t.Errorf("test %d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestBlockSortThen(t *testing.T) {
+	block := func(number, difficulty int64, time uint64) *Block {
+		return NewBlockWithHeader(&Header{Number: big.NewInt(number), Difficulty: big.NewInt(difficulty), Time: time})
+	}
+	var (
+		a = block(2, 300, 1)
+		b = block(2, 100, 2)
+		c = block(2, 200, 3)
+		d = block(1, 500, 4)
+	)
+	blocks := Blocks{a, b, c, d}
+	BlockBy(ByNumberDesc).Then(ByDifficulty).Sort(blocks)
+	if want := (Blocks{b, c, a, d}); !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("unexpected number/difficulty order: have %v, want %v", blocks, want)
+	}
+	BlockBy(ByTime).Sort(blocks)
+	if want := (Blocks{a, b, c, d}); !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("unexpected time order: have %v, want %v", blocks, want)
+	}
+	unset := &Block{header: &Header{}}
+	blocks = Blocks{unset, d, a}
+	BlockBy(ByNumberDesc).Sort(blocks)
+	if want := (Blocks{a, d, unset}); !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("unexpected order without number: have %v, want %v", blocks, want)
+	}
+	BlockBy(ByDifficulty).Sort(blocks)
+	if want := (Blocks{unset, a, d}); !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("unexpected order without difficulty: have %v, want %v", blocks, want)
+	}
+}
+
+func TestHeaderSanityCheck(t *testing.T) {
+	tests := []struct {
+		header *Header
+		valid  bool
+	}{
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(131072), BaseFee: big.NewInt(params.InitialBaseFee)}, true},
+		{&Header{Number: big.NewInt(1), Difficulty: new(big.Int).SetBytes(make([]byte, 40))}, true}, // zero, leading bytes don't count
+		{&Header{Number: big.NewInt(1), Difficulty: new(big.Int).SetBytes(bytes.Repeat([]byte{0xff}, 40))}, false},
+		{&Header{Number: new(big.Int).SetBytes(bytes.Repeat([]byte{0xff}, 40)), Difficulty: big.NewInt(1)}, false},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), BaseFee: new(big.Int).SetBytes(bytes.Repeat([]byte{0xff}, 40))}, false},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), Extra: make([]byte, HeaderExtraMax+1)}, false},
+		{&Header{Difficulty: big.NewInt(1)}, false},
+		{&Header{Number: big.NewInt(1)}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.header.SanityCheck(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestBlockDistinctSenders(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		signer  = HomesteadSigner{}
+	)
+	header := &Header{Number: big.NewInt(1)}
+
+	block := NewBlock(header, []*Transaction{signTestTx(t, signer, key1, 0), signTestTx(t, signer, key2, 0), signTestTx(t, signer, key1, 1)}, nil, nil, newHasher())
+	if n, err := block.DistinctSenders(signer); err != nil || n != 2 {
+		t.Fatalf("distinct senders mismatch: have %d (%v), want 2", n, err)
+	}
+	if n, err := NewBlockWithHeader(header).DistinctSenders(signer); err != nil || n != 0 {
+		t.Fatalf("empty block has senders: %d (%v)", n, err)
+	}
+	unsigned := NewBlock(header, []*Transaction{NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)}, nil, nil, newHasher())
+	if _, err := unsigned.DistinctSenders(signer); err == nil {
+		t.Fatal("unsigned transaction accepted")
+	}
+}
+
+func TestBlockTimestampStrictlyAfter(t *testing.T) {
+	parent := &Header{Number: big.NewInt(1), Time: 1000}
+	tests := []struct {
+		time uint64
+		want bool
+	}{
+		{999, false},
+		{1000, false},
+		{1001, true},
+	}
+	for _, tt := range tests {
+		block := NewBlockWithHeader(&Header{Number: big.NewInt(2), Time: tt.time})
+		if have := block.TimestampStrictlyAfter(parent); have != tt.want {
+			t.Errorf("time %d: have %v, want %v", tt.time, have, tt.want)
+		}
+	}
+}
+
+func TestHeaderTimeTime(t *testing.T) {
+	header := &Header{Number: big.NewInt(1), Time: 1<<32 + 1}
+	want := time.Date(2106, time.February, 7, 6, 28, 17, 0, time.UTC)
+	if have := header.TimeTime(); !have.Equal(want) || have.Location() != time.UTC {
+		t.Fatalf("header time mismatch: have %v, want %v", have, want)
+	}
+	if have := NewBlockWithHeader(header).TimeTime(); !have.Equal(want) {
+		t.Fatalf("block time mismatch: have %v, want %v", have, want)
+	}
+	if header.TimeTime().Unix() < 0 {
+		t.Fatal("timestamp wrapped to the past")
+	}
+}
+
+func TestBlockUncleRLP(t *testing.T) {
+	block := makeBenchBlock()
+	for i, uncle := range block.Uncles() {
+		enc, err := block.UncleRLP(i)
+		if err != nil {
+			t.Fatalf("uncle %d: %v", i, err)
+		}
+		want, _ := rlp.EncodeToBytes(uncle)
+		if !bytes.Equal(enc, want) {
+			t.Fatalf("uncle %d: encoding mismatch:\nhave %x\nwant %x", i, enc, want)
+		}
+		if hash := crypto.Keccak256Hash(enc); hash != uncle.Hash() {
+			t.Fatalf("uncle %d: hash mismatch: have %x, want %x", i, hash, uncle.Hash())
+		}
+	}
+	for _, i := range []int{-1, len(block.Uncles())} {
+		if _, err := block.UncleRLP(i); err == nil {
+			t.Fatalf("out of range index %d accepted", i)
+		}
+	}
+}
+
+func TestBlockDeepCopy(t *testing.T) {
+	block := makeBenchBlock()
+	block.ReceivedAt = time.Unix(1000, 0)
+	var (
+		hash   = block.Hash()
+		first  = block.Transactions()[0]
+		uncle  = block.Uncles()[0].Hash()
+		number = block.NumberU64()
+	)
+	cpy := block.DeepCopy()
+	if cpy.Hash() != hash || cpy.Size() != block.Size() || !cpy.ReceivedAt.Equal(block.ReceivedAt) {
+		t.Fatal("copy differs from the original")
+	}
+	cpy.header.Number.SetUint64(number + 1)
+	cpy.header.Extra[0] = 'X'
+	cpy.transactions[0] = block.transactions[1]
+	cpy.uncles[0].Difficulty.SetUint64(1)
+	cpy.ReceivedAt = time.Time{}
+
+	if block.NumberU64() != number || block.Header().Hash() != hash {
+		t.Fatal("original header modified through copy")
+	}
+	if block.Transactions()[0] != first {
+		t.Fatal("original transactions modified through copy")
+	}
+	if block.Uncles()[0].Hash() != uncle {
+		t.Fatal("original uncles modified through copy")
+	}
+	if block.ReceivedAt.IsZero() {
+		t.Fatal("original relay metadata modified through copy")
+	}
+}
+
+func TestBlockBlocksBehind(t *testing.T) {
+	head := &Header{Number: big.NewInt(100)}
+	tests := []struct {
+		number *big.Int
+		head   *Header
+		behind uint64
+		ok     bool
+	}{
+		{big.NewInt(90), head, 10, true},
+		{big.NewInt(100), head, 0, true},
+		{big.NewInt(101), head, 0, false},
+		{big.NewInt(90), &Header{}, 0, false},
+		{big.NewInt(90), nil, 0, false},
+		{nil, head, 0, false},
+	}
+	for i, tt := range tests {
+		block := &Block{header: &Header{Number: tt.number}}
+		if behind, ok := block.BlocksBehind(tt.head); behind != tt.behind || ok != tt.ok {
+			t.Errorf("test %d: have (%d, %v), want (%d, %v)", i, behind, ok, tt.behind, tt.ok)
+		}
+	}
+}
+
+func TestBlockChainable(t *testing.T) {
+	var c Chainable = &Block{header: &Header{ParentHash: common.Hash{1}}}
+	if n := c.NumberU64(); n != 0 {
+		t.Fatalf("block without number: have %d, want 0", n)
+	}
+	block := NewBlockWithHeader(&Header{Number: big.NewInt(5), ParentHash: common.Hash{1}})
+	c = block
+	if c.NumberU64() != 5 || c.ParentHash() != (common.Hash{1}) || c.Hash() != block.Hash() {
+		t.Fatal("chainable view mismatch")
+	}
+}
+
+func TestBlockValidateGas(t *testing.T) {
+	tests := []struct {
+		used, limit uint64
+		valid       bool
+	}{
+		{5000001, 5000000, false},
+		{5000000, 5000000, true},
+		{21000, 5000000, true},
+	}
+	for i, tt := range tests {
+		block := NewBlockWithHeader(&Header{Number: big.NewInt(1), GasUsed: tt.used, GasLimit: tt.limit})
+		if err := block.ValidateGas(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestBlockCoinbaseGasReward(t *testing.T) {
+	txs := newTestTxs(10, 3)
+	// Receipts without logs, as kept after trimming.
+	receipts := newTestReceipts(21000, 30000)
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+
+	price := func(tx *Transaction) *big.Int { return new(big.Int).SetUint64(tx.Nonce() + 1) }
+	reward, err := block.CoinbaseGasReward(receipts, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := big.NewInt(21000*1 + 30000*2); reward.Cmp(want) != 0 {
+		t.Fatalf("reward mismatch: have %v, want %v", reward, want)
+	}
+	if _, err := block.CoinbaseGasReward(receipts[:1], price); err == nil {
+		t.Fatal("misaligned receipts accepted")
+	}
+	decreasing := Receipts{{CumulativeGasUsed: 42000}, {CumulativeGasUsed: 21000}}
+	if reward, err := block.CoinbaseGasReward(decreasing, price); err == nil {
+		t.Fatalf("decreasing cumulative gas accepted, reward %v", reward)
+	}
+}
+
+func TestBlockEnvelopeRLP(t *testing.T) {
+	block := makeBenchBlock()
+	enc, err := rlp.EncodeToBytes(block.Envelope(5))
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var env BlockEnvelope
+	if err := rlp.DecodeBytes(enc, &env); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if env.Version != BlockEnvelopeVersion || env.NetworkID != 5 {
+		t.Fatalf("envelope metadata mismatch: version %d, network %d", env.Version, env.NetworkID)
+	}
+	if env.Block.Hash() != block.Hash() || len(env.Block.Transactions()) != len(block.Transactions()) {
+		t.Fatal("envelope block mismatch")
+	}
+
+	future := &BlockEnvelope{Version: BlockEnvelopeVersion + 1, NetworkID: 5, Block: block}
+	if enc, err = rlp.EncodeToBytes(future); err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if err := rlp.DecodeBytes(enc, &env); err == nil || !strings.Contains(err.Error(), "unsupported block envelope version 2") {
+		t.Fatalf("unknown version not rejected: %v", err)
+	}
+}
+
+func TestNewGenesisBlock(t *testing.T) {
+	var (
+		coinbase   = common.HexToAddress("0xc0ffee")
+		difficulty = big.NewInt(131072)
+	)
+	genesis := NewGenesisBlock(coinbase, difficulty, 5000, 1600000000, []byte("genesis"))
+	if genesis.NumberU64() != 0 || genesis.Number().Sign() != 0 {
+		t.Fatalf("genesis number not zero: %v", genesis.Number())
+	}
+	if genesis.ParentHash() != (common.Hash{}) {
+		t.Fatalf("genesis parent hash not zero: %x", genesis.ParentHash())
+	}
+	want := NewBlock(&Header{Coinbase: coinbase, Difficulty: difficulty, Number: big.NewInt(0), GasLimit: 5000, Time: 1600000000, Extra: []byte("genesis")}, nil, nil, nil, newHasher())
+	if genesis.Hash() != want.Hash() {
+		t.Fatalf("genesis header mismatch: %v", DiffHeaders(genesis.Header(), want.Header()))
+	}
+	if again := NewGenesisBlock(coinbase, difficulty, 5000, 1600000000, []byte("genesis")); again.Hash() != genesis.Hash() {
+		t.Fatalf("genesis hash not deterministic: %x != %x", again.Hash(), genesis.Hash())
+	}
+	if err := genesis.SanityCheck(); err != nil {
+		t.Fatalf("genesis fails sanity check: %v", err)
+	}
+}
+
+func TestTxDiff(t *testing.T) {
+	tx := func(nonce uint64) *Transaction {
+		return NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	}
+	var (
+		header   = &Header{Number: big.NewInt(1)}
+		oldBlock = NewBlockWithHeader(header).WithBody([]*Transaction{tx(0), tx(1), tx(2)}, nil)
+		newBlock = NewBlockWithHeader(header).WithBody([]*Transaction{tx(2), tx(3), tx(0)}, nil)
+	)
+	dropped, added := TxDiff(oldBlock, newBlock)
+	if len(dropped) != 1 || dropped[0].Nonce() != 1 {
+		t.Errorf("dropped mismatch: %v", dropped)
+	}
+	if len(added) != 1 || added[0].Nonce() != 3 {
+		t.Errorf("added mismatch: %v", added)
+	}
+	if dropped, added := TxDiff(oldBlock, oldBlock); len(dropped) != 0 || len(added) != 0 {
+		t.Errorf("block differs from itself: dropped %v, added %v", dropped, added)
+	}
+}
+
+func TestBlockGenesisConsistent(t *testing.T) {
+	tests := []struct {
+		header *Header
+		valid  bool
+	}{
+		{&Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}, true},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), ParentHash: common.Hash{1}}, true},
+		{&Header{Number: big.NewInt(0), Difficulty: big.NewInt(1), ParentHash: common.Hash{1}}, false},
+		{&Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}, false},
+		{&Header{Difficulty: big.NewInt(1)}, false},
+	}
+	for i, tt := range tests {
+		block := &Block{header: tt.header}
+		if have := block.GenesisConsistent(); have != tt.valid {
+			t.Errorf("test %d: consistency mismatch: have %v, want %v", i, have, tt.valid)
+		}
+		if err := block.SanityCheck(); (err == nil) != tt.valid {
+			t.Errorf("test %d: sanity check mismatch: %v", i, err)
+		}
+	}
+}
+
+func TestHeaderEqual(t *testing.T) {
+	a := &Header{Number: big.NewInt(1), Difficulty: big.NewInt(100), Nonce: EncodeNonce(7), Extra: []byte("a")}
+	if !a.Equal(a.Copy()) {
+		t.Fatal("header not equal to its copy")
+	}
+	b := a.Copy()
+	b.Extra = []byte("b")
+	if a.Equal(b) {
+		t.Fatal("headers with different extra-data reported equal")
+	}
+	if a.Nonce != b.Nonce {
+		t.Fatal("test headers should share a nonce")
+	}
+	c := a.Copy()
+	c.Number = new(big.Int).SetBytes([]byte{1}) // same value, different pointer
+	if !a.Equal(c) {
+		t.Fatal("big integers compared by pointer")
+	}
+	c.BaseFee = big.NewInt(1)
+	if a.Equal(c) {
+		t.Fatal("missing base fee reported equal to a set one")
+	}
+	if a.Equal(nil) || !(*Header)(nil).Equal(nil) {
+		t.Fatal("nil handling mismatch")
+	}
+}
+
+func TestBlocksTotalEncodedSize(t *testing.T) {
+	blocks := Blocks{makeBenchBlock(), NewBlockWithHeader(&Header{Number: big.NewInt(1)}), makeBenchBlock()}
+	total, err := blocks.TotalEncodedSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, b := range blocks {
+		enc, err := rlp.EncodeToBytes(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want += len(enc)
+	}
+	if total != want {
+		t.Fatalf("total size mismatch: have %d, want %d", total, want)
+	}
+	if total, err := (Blocks{}).TotalEncodedSize(); err != nil || total != 0 {
+		t.Fatalf("empty batch size: have %d (%v), want 0", total, err)
+	}
+}
+
+func TestReceiptLookup(t *testing.T) {
+	var (
+		txs      = newTestTxs(1, 1, 1)
+		receipts = newTestReceipts(21000, 21000, 21000)
+		block    = NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, receipts, newHasher())
+		missing  = common.HexToHash("0xdead")
+	)
+	for i, tx := range txs {
+		if have := block.ReceiptForTx(receipts, tx.Hash()); have != receipts[i] {
+			t.Errorf("tx %d: wrong block receipt: %v", i, have)
+		}
+	}
+	if have := block.ReceiptForTx(receipts, missing); have != nil {
+		t.Errorf("receipt found for missing transaction: %v", have)
+	}
+	if have := block.ReceiptForTx(receipts[:2], txs[0].Hash()); have != nil {
+		t.Errorf("receipt found in misaligned receipts: %v", have)
+	}
+	// Lookups on the receipt list itself rely on the derived transaction hashes.
+	if err := receipts.DeriveFields(params.TestChainConfig, block.Hash(), block.NumberU64(), block.Transactions()); err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range txs {
+		if have := receipts.Find(tx.Hash()); have != receipts[i] {
+			t.Errorf("tx %d: wrong receipt: %v", i, have)
+		}
+	}
+	if have := receipts.Find(missing); have != nil {
+		t.Errorf("receipt found for missing transaction: %v", have)
+	}
+}
+
+func TestBlocksGasLimitVolatility(t *testing.T) {
+	batch := func(limits ...uint64) Blocks {
+		blocks := make(Blocks, len(limits))
+		for i, limit := range limits {
+			blocks[i] = NewBlockWithHeader(&Header{Number: big.NewInt(int64(i)), GasLimit: limit})
+		}
+		return blocks
+	}
+	stable := batch(8000000, 8000000, 8000000, 8000000).GasLimitVolatility()
+	if stable != 0 {
+		t.Errorf("stable batch volatility: have %v, want 0", stable)
+	}
+	oscillating := batch(8000000, 10000000, 8000000, 10000000).GasLimitVolatility()
+	if oscillating != 1000000 {
+		t.Errorf("oscillating batch volatility: have %v, want 1000000", oscillating)
+	}
+	if v := batch().GasLimitVolatility(); v != 0 {
+		t.Errorf("empty batch volatility: have %v, want 0", v)
+	}
+}
+
+func TestHeaderEpoch(t *testing.T) {
+	tests := []struct {
+		number *big.Int
+		want   uint64
+	}{
+		{big.NewInt(0), 0},
+		{big.NewInt(29999), 0},
+		{big.NewInt(30000), 1},
+		{big.NewInt(60001), 2},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if have := (&Header{Number: tt.number}).Epoch(30000); have != tt.want {
+			t.Errorf("number %v: epoch mismatch: have %d, want %d", tt.number, have, tt.want)
+		}
+	}
+	if have := (&Header{Number: big.NewInt(60001)}).Epoch(0); have != 0 {
+		t.Errorf("zero epoch length: epoch mismatch: have %d, want 0", have)
+	}
+}
+
+func TestBlockSingleSender(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		signer  = HomesteadSigner{}
+		header  = &Header{Number: big.NewInt(1)}
+	)
+	single := NewBlock(header, []*Transaction{signTestTx(t, signer, key1, 0), signTestTx(t, signer, key1, 1)}, nil, nil, newHasher())
+	if from, ok := single.SingleSender(signer); !ok || from != crypto.PubkeyToAddress(key1.PublicKey) {
+		t.Fatalf("single sender not detected: %x %v", from, ok)
+	}
+	mixed := NewBlock(header, []*Transaction{signTestTx(t, signer, key1, 0), signTestTx(t, signer, key2, 0)}, nil, nil, newHasher())
+	if from, ok := mixed.SingleSender(signer); ok || from != (common.Address{}) {
+		t.Fatalf("mixed senders reported as single: %x", from)
+	}
+	if _, ok := NewBlockWithHeader(header).SingleSender(signer); ok {
+		t.Fatal("empty block reported a single sender")
+	}
+}
+
+func TestBlockSealHash(t *testing.T) {
+	header := &Header{Number: big.NewInt(1), Difficulty: big.NewInt(131072), Nonce: EncodeNonce(1)}
+	block := NewBlockWithHeader(header)
+	if block.SealHash() != header.HashNoNonce() {
+		t.Fatalf("seal hash mismatch: have %x, want %x", block.SealHash(), header.HashNoNonce())
+	}
+	sealed := block.Header()
+	sealed.Nonce = EncodeNonce(2)
+	sealed.MixDigest = common.Hash{1}
+	resealed := block.WithSeal(sealed)
+	if resealed.SealHash() != block.SealHash() {
+		t.Fatal("seal hash changed with the seal")
+	}
+	if resealed.Hash() == block.Hash() {
+		t.Fatal("block hash unchanged with the seal")
+	}
+}
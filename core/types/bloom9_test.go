@@ -54,6 +54,24 @@ func TestBloom(t *testing.T) {
 	}
 }
 
+func TestBloomTestAddress(t *testing.T) {
+	var (
+		addr     = common.HexToAddress("0x8bde3d0e33c6b1fbb8fa6b7e3ef27b6a7f1e9cb0")
+		other    = common.HexToAddress("0x01")
+		receipts = Receipts{{Logs: []*Log{{Address: addr}}}}
+		bloom    = CreateBloom(receipts)
+	)
+	if !bloom.TestAddress(addr) {
+		t.Error("expected log address to test true")
+	}
+	if bloom.TestAddress(other) {
+		t.Error("did not expect unrelated address to test true")
+	}
+	if !BloomLookup(bloom, addr) {
+		t.Error("expected BloomLookup of log address to test true")
+	}
+}
+
 // TestBloomExtensively does some more thorough tests
 func TestBloomExtensively(t *testing.T) {
 	var exp = common.HexToHash("c8d3ca65cdb4874300a9e39475508f23ed6da09fdbc487f89a2dcf50b09eb263")
@@ -18,6 +18,7 @@ package types
 
 import (
 	"bytes"
+	"runtime"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -85,29 +86,73 @@ func encodeForDerive(list DerivableList, i int, buf *bytes.Buffer) []byte {
 
 // DeriveSha creates the tree hashes of transactions and receipts in a block header.
 func DeriveSha(list DerivableList, hasher TrieHasher) common.Hash {
-	hasher.Reset()
-
 	valueBuf := encodeBufferPool.Get().(*bytes.Buffer)
 	defer encodeBufferPool.Put(valueBuf)
 
+	return deriveSha(list.Len(), hasher, func(i int) []byte {
+		return encodeForDerive(list, i, valueBuf)
+	})
+}
+
+// deriveShaParallelThreshold is the list length from which DeriveShaParallel
+// spreads the encoding of the list entries across multiple goroutines.
+const deriveShaParallelThreshold = 1024
+
+// DeriveShaParallel creates the same tree hash as DeriveSha, but RLP encodes the
+// list entries concurrently for large lists. The trie hashing itself remains
+// sequential. EncodeIndex of the list must be safe for concurrent use.
+func DeriveShaParallel(list DerivableList, hasher TrieHasher) common.Hash {
+	n := list.Len()
+	if n < deriveShaParallelThreshold {
+		return DeriveSha(list, hasher)
+	}
+	var (
+		values  = make([][]byte, n)
+		workers = runtime.NumCPU()
+		chunk   = (n + workers - 1) / workers
+		pend    sync.WaitGroup
+	)
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		pend.Add(1)
+		go func(start, end int) {
+			defer pend.Done()
+
+			buf := encodeBufferPool.Get().(*bytes.Buffer)
+			defer encodeBufferPool.Put(buf)
+			for i := start; i < end; i++ {
+				values[i] = encodeForDerive(list, i, buf)
+			}
+		}(start, end)
+	}
+	pend.Wait()
+
+	return deriveSha(n, hasher, func(i int) []byte { return values[i] })
+}
+
+// deriveSha inserts the n values returned by value into the hasher, keyed by
+// their RLP encoded index, and returns the resulting root.
+func deriveSha(n int, hasher TrieHasher, value func(int) []byte) common.Hash {
+	hasher.Reset()
+
 	// StackTrie requires values to be inserted in increasing hash order, which is not the
 	// order that `list` provides hashes in. This insertion sequence ensures that the
 	// order is correct.
 	var indexBuf []byte
-	for i := 1; i < list.Len() && i <= 0x7f; i++ {
+	for i := 1; i < n && i <= 0x7f; i++ {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], uint64(i))
-		value := encodeForDerive(list, i, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(i))
 	}
-	if list.Len() > 0 {
+	if n > 0 {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], 0)
-		value := encodeForDerive(list, 0, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(0))
 	}
-	for i := 0x80; i < list.Len(); i++ {
+	for i := 0x80; i < n; i++ {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], uint64(i))
-		value := encodeForDerive(list, i, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(i))
 	}
 	return hasher.Hash()
 }
@@ -102,6 +102,39 @@ func BenchmarkDeriveSha200(b *testing.B) {
 	}
 }
 
+func TestDeriveShaParallel(t *testing.T) {
+	for _, n := range []uint64{0, 1, 200, 5000} {
+		txs, err := genTxs(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := types.DeriveSha(txs, trie.NewStackTrie(nil))
+		got := types.DeriveShaParallel(txs, trie.NewStackTrie(nil))
+		if got != exp {
+			t.Fatalf("%d txs: got %x exp %x", n, got, exp)
+		}
+	}
+}
+
+func BenchmarkDeriveSha5000(b *testing.B) {
+	txs, err := genTxs(5000)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("serial", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			types.DeriveSha(txs, trie.NewStackTrie(nil))
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			types.DeriveShaParallel(txs, trie.NewStackTrie(nil))
+		}
+	})
+}
+
 func TestFuzzDeriveSha(t *testing.T) {
 	// increase this for longer runs -- it's set to quite low for travis
 	rndSeed := mrand.Int()
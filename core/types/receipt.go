@@ -394,6 +394,48 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	}
 }
 
+// Find returns the receipt of the transaction with the given hash, or nil if none
+// is found. The receipts are matched on their TxHash, which is a derived field,
+// so they must have gone through DeriveFields.
+func (rs Receipts) Find(txHash common.Hash) *Receipt {
+	for _, receipt := range rs {
+		if receipt.TxHash == txHash {
+			return receipt
+		}
+	}
+	return nil
+}
+
+// checkCumulativeGas ensures that the cumulative gas used of the receipts never
+// decreases, so the gas used by each transaction can be taken as the difference
+// to the previous receipt.
+func (rs Receipts) checkCumulativeGas() error {
+	var prev uint64
+	for i, receipt := range rs {
+		if receipt.CumulativeGasUsed < prev {
+			return fmt.Errorf("receipt %d: cumulative gas used decreased from %d to %d", i, prev, receipt.CumulativeGasUsed)
+		}
+		prev = receipt.CumulativeGasUsed
+	}
+	return nil
+}
+
+// ValidateCumulativeGas checks that the cumulative gas used of the receipts never
+// decreases and that it ends at the given gas used by the block.
+func (rs Receipts) ValidateCumulativeGas(blockGasUsed uint64) error {
+	if err := rs.checkCumulativeGas(); err != nil {
+		return err
+	}
+	var prev uint64
+	if len(rs) > 0 {
+		prev = rs[len(rs)-1].CumulativeGasUsed
+	}
+	if prev != blockGasUsed {
+		return fmt.Errorf("cumulative gas used mismatch: have %d, block %d", prev, blockGasUsed)
+	}
+	return nil
+}
+
 // DeriveFields fills the receipts with their computed fields based on consensus
 // data and contextual infos like containing block and transactions.
 func (rs Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions) error {
@@ -502,3 +502,26 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+func TestReceiptsValidateCumulativeGas(t *testing.T) {
+	tests := []struct {
+		gas   []uint64
+		used  uint64
+		valid bool
+	}{
+		{[]uint64{21000, 42000, 42000, 100000}, 100000, true},
+		{nil, 0, true},
+		{[]uint64{21000, 20000, 100000}, 100000, false}, // non-monotonic
+		{[]uint64{21000, 42000}, 50000, false},          // final mismatch
+		{nil, 21000, false},
+	}
+	for i, tt := range tests {
+		receipts := make(Receipts, len(tt.gas))
+		for j, gas := range tt.gas {
+			receipts[j] = &Receipt{CumulativeGasUsed: gas}
+		}
+		if err := receipts.ValidateCumulativeGas(tt.used); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
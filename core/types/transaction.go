@@ -414,6 +414,32 @@ func (s Transactions) EncodeIndex(i int, w *bytes.Buffer) {
 	}
 }
 
+// AverageGasPrice returns the integer mean of the gas prices of the transactions,
+// or nil if there are none.
+func (s Transactions) AverageGasPrice() *big.Int {
+	if len(s) == 0 {
+		return nil
+	}
+	sum := new(big.Int)
+	for _, tx := range s {
+		sum.Add(sum, tx.inner.gasPrice())
+	}
+	return sum.Div(sum, big.NewInt(int64(len(s))))
+}
+
+// FilterFrom returns the transactions sent by addr, in order. Senders are
+// recovered with the given signer; transactions whose sender cannot be
+// recovered are skipped.
+func (s Transactions) FilterFrom(signer Signer, addr common.Address) Transactions {
+	txs := make(Transactions, 0)
+	for _, tx := range s {
+		if from, err := Sender(signer, tx); err == nil && from == addr {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
 // TxDifference returns a new set which is the difference between a and b.
 func TxDifference(a, b Transactions) Transactions {
 	keep := make(Transactions, 0, len(a))
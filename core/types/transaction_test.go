@@ -531,3 +531,50 @@ func assertEqual(orig *Transaction, cpy *Transaction) error {
 	}
 	return nil
 }
+
+func TestTransactionsAverageGasPrice(t *testing.T) {
+	if avg := Transactions(nil).AverageGasPrice(); avg != nil {
+		t.Fatalf("average of no transactions: have %v, want nil", avg)
+	}
+	var txs Transactions
+	for i, price := range []int64{10, 20, 25} {
+		txs = append(txs, NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 21000, big.NewInt(price), nil))
+	}
+	if avg := txs.AverageGasPrice(); avg.Cmp(big.NewInt(18)) != 0 {
+		t.Fatalf("average gas price mismatch: have %v, want 18", avg)
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, nil, newHasher())
+	if avg := block.AverageGasPrice(); avg.Cmp(big.NewInt(18)) != 0 {
+		t.Fatalf("block average gas price mismatch: have %v, want 18", avg)
+	}
+}
+
+func TestTransactionsFilterFrom(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		signer  = HomesteadSigner{}
+		txs     Transactions
+	)
+	for i, key := range []*ecdsa.PrivateKey{key1, key2, key1} {
+		tx, err := SignTx(NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+	// An unsigned transaction has no recoverable sender and must be skipped.
+	txs = append(txs, NewTransaction(3, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+
+	if have := txs.FilterFrom(signer, addr1); len(have) != 2 || have[0] != txs[0] || have[1] != txs[2] {
+		t.Fatalf("wrong transactions from addr1: %v", have)
+	}
+	block := NewBlock(&Header{Number: big.NewInt(1)}, txs, nil, nil, newHasher())
+	if have := block.TransactionsFrom(signer, crypto.PubkeyToAddress(key2.PublicKey)); len(have) != 1 || have[0] != txs[1] {
+		t.Fatalf("wrong block transactions from addr2: %v", have)
+	}
+	if have := block.TransactionsFrom(signer, common.Address{}); have == nil || len(have) != 0 {
+		t.Fatalf("unexpected transactions from zero address: %v", have)
+	}
+}
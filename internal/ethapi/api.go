@@ -352,12 +352,24 @@ func (s *PersonalAccountAPI) DeriveAccount(url string, path string, pin *bool) (
 }
 
 // NewAccount will create a new account and returns the address for the new account.
-func (s *PersonalAccountAPI) NewAccount(password string) (common.Address, error) {
+// The optional strength selects the key derivation cost of the key file, either
+// "light" or "standard". If omitted, the cost configured for the keystore is used.
+func (s *PersonalAccountAPI) NewAccount(password string, strength *string) (common.Address, error) {
 	ks, err := fetchKeystore(s.am)
 	if err != nil {
 		return common.Address{}, err
 	}
-	acc, err := ks.NewAccount(password)
+	var acc accounts.Account
+	switch {
+	case strength == nil:
+		acc, err = ks.NewAccount(password)
+	case *strength == "light":
+		acc, err = ks.NewAccountWithScrypt(password, keystore.LightScryptN, keystore.LightScryptP)
+	case *strength == "standard":
+		acc, err = ks.NewAccountWithScrypt(password, keystore.StandardScryptN, keystore.StandardScryptP)
+	default:
+		return common.Address{}, fmt.Errorf("unknown key derivation strength %q", *strength)
+	}
 	if err == nil {
 		log.Info("Your new key was generated", "address", acc.Address)
 		log.Warn("Please backup your key file!", "path", acc.URL.Path)
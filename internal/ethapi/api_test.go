@@ -0,0 +1,94 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestRPCMarshalBlock(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSigner(params.TestChainConfig)
+	tx, err := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{
+		ParentHash: common.Hash{1},
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(100),
+		GasLimit:   8000000,
+		GasUsed:    21000,
+		Time:       1426516743,
+		Extra:      []byte("extra"),
+	}
+	block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil, trie.NewStackTrie(nil))
+
+	for _, fullTx := range []bool{false, true} {
+		fields, err := RPCMarshalBlock(block, true, fullTx, params.TestChainConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := json.Marshal(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(enc, &result); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{
+			"number", "hash", "parentHash", "nonce", "mixHash", "sha3Uncles", "logsBloom", "transactionsRoot",
+			"stateRoot", "receiptsRoot", "miner", "difficulty", "extraData", "size", "gasLimit", "gasUsed", "timestamp",
+		} {
+			value, ok := result[field].(string)
+			if !ok {
+				t.Errorf("fullTx=%v: field %q missing or not a string: %v", fullTx, field, result[field])
+				continue
+			}
+			if !strings.HasPrefix(value, "0x") {
+				t.Errorf("fullTx=%v: field %q not hex encoded: %s", fullTx, field, value)
+			}
+		}
+		if have, want := result["number"], "0x64"; have != want {
+			t.Errorf("fullTx=%v: number mismatch: have %v, want %s", fullTx, have, want)
+		}
+		if have, want := result["hash"], block.Hash().Hex(); have != want {
+			t.Errorf("fullTx=%v: hash mismatch: have %v, want %s", fullTx, have, want)
+		}
+		txs, ok := result["transactions"].([]interface{})
+		if !ok || len(txs) != 1 {
+			t.Fatalf("fullTx=%v: transactions mismatch: %v", fullTx, result["transactions"])
+		}
+		if fullTx {
+			if obj, ok := txs[0].(map[string]interface{}); !ok || obj["hash"] != tx.Hash().Hex() {
+				t.Errorf("full transaction mismatch: %v", txs[0])
+			}
+		} else if txs[0] != tx.Hash().Hex() {
+			t.Errorf("transaction hash mismatch: have %v, want %s", txs[0], tx.Hash().Hex())
+		}
+	}
+}
@@ -686,6 +686,11 @@ const PersonalJs = `
 web3._extend({
 	property: 'personal',
 	methods: [
+		new web3._extend.Method({
+			name: 'newAccountWithStrength',
+			call: 'personal_newAccount',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'importRawKey',
 			call: 'personal_importRawKey',
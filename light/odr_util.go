@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // errNonCanonicalHash is returned if the requested chain data doesn't belong
@@ -183,6 +185,23 @@ func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, num
 	return receipts, nil
 }
 
+// ReceiptProof builds a Merkle proof of the receipt at the given index, which can
+// be verified against the receipt root in the header of the block the receipts
+// belong to.
+func ReceiptProof(receipts types.Receipts, index int) (NodeList, error) {
+	if index < 0 || index >= len(receipts) {
+		return nil, fmt.Errorf("receipt index %d out of range [0, %d)", index, len(receipts))
+	}
+	tr := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	types.DeriveSha(receipts, tr)
+
+	var proof NodeList
+	if err := tr.Prove(rlp.AppendUint64(nil, uint64(index)), 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
 // GetBlockLogs retrieves the logs generated by the transactions included in a
 // block given by its hash.
 func GetBlockLogs(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -0,0 +1,60 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestReceiptProof(t *testing.T) {
+	var receipts types.Receipts
+	for i := 0; i < 200; i++ {
+		receipts = append(receipts, &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			Logs:              []*types.Log{{Address: common.Address{byte(i)}}},
+		})
+	}
+	root := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+
+	for _, index := range []int{0, 1, 127, 128, 199} {
+		proof, err := ReceiptProof(receipts, index)
+		if err != nil {
+			t.Fatalf("receipt %d: failed to create proof: %v", index, err)
+		}
+		value, err := trie.VerifyProof(root, rlp.AppendUint64(nil, uint64(index)), proof.NodeSet())
+		if err != nil {
+			t.Fatalf("receipt %d: invalid proof: %v", index, err)
+		}
+		var want bytes.Buffer
+		receipts.EncodeIndex(index, &want)
+		if !bytes.Equal(value, want.Bytes()) {
+			t.Fatalf("receipt %d: proven value mismatch: have %x, want %x", index, value, want.Bytes())
+		}
+	}
+	for _, index := range []int{-1, len(receipts)} {
+		if _, err := ReceiptProof(receipts, index); err == nil {
+			t.Errorf("receipt %d: out of range index accepted", index)
+		}
+	}
+}